@@ -0,0 +1,247 @@
+package expath
+
+import (
+	"os"
+	"unicode/utf8"
+)
+
+// MatchCaptures reports whether name matches the shell file name pattern, the same as Match,
+// and returns the text matched by each wildcard of the pattern, in the order of the wildcards:
+//
+//	'**'         captures the directories it matched, without the leading and trailing separators
+//	'*'          captures the (maybe empty) text it matched in a path element
+//	'?', '[...]' captures the character it matched
+//
+// For example, `src/**/*.proto` on `src/a/b/c.proto` captures ["a/b", "c"].
+//
+// If the wildcards could match in more than one way, the captures are chosen deterministically:
+// each '**' matches as few directories as possible from left to right, and then
+// each '*' matches as few characters as possible from left to right.
+//
+// The wildcards are of the pattern cleaned of the '.' and '..' dirs, and the name that matches only the pattern
+// as written is captured by them after it's cleaned the same.
+//
+func MatchCaptures(pattern, name string) (captures []string, matched bool, err error) {
+	segs, literal, err := dotsSegments(pattern)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return matchCapturesDots(segs, literal, name)
+}
+
+func matchCaptures(segs []patternSeg, name string) (captures []string, matched bool, err error) {
+	var spans []segSpan
+
+	switch len(segs) {
+	case 0:
+		matched, err = matchASeg(patternSeg{"", 0}, name)
+		return nil, matched, err
+	case 1:
+		if matched, err = matchASeg(segs[0], name); !matched {
+			return nil, matched, err
+		}
+		if segs[0].dirs >= 0 {
+			spans = append(spans, segSpan{0, segs[0].pattern, 0, len(name)})
+		}
+	default:
+		if matched, err = matchSegs(segs, name, &spans); !matched {
+			return nil, matched, err
+		}
+	}
+
+	captures = []string{}
+
+	var from, k int
+	for _, seg := range segs {
+		if seg.dirs < 0 {
+			to := len(name)
+			if k < len(spans) {
+				to = spans[k].from
+			}
+			captures = append(captures, trimSeparators(name[from:to]))
+			continue
+		}
+
+		span := spans[k]
+		k++
+
+		captures, _ = captureSeg(span.pattern, name[span.from:span.to], captures)
+		from = span.to
+	}
+
+	return captures, true, nil
+}
+
+func trimSeparators(name string) string {
+	i, j := 0, len(name)
+	for i < j && isDirSeparator(name, i) {
+		i++
+	}
+	for j > i && isDirSeparator(name, j-1) {
+		j--
+	}
+	return name[i:j]
+}
+
+// captureSeg matches the normal pattern segment against the name (just as filepath.Match),
+// and appends the text matched by each wildcard to the captures.
+// Each '*' matches as few characters as possible.
+//
+func captureSeg(pattern, name string, captures []string) ([]string, bool) {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+
+			n := len(captures)
+			for i := 0; ; {
+				if c, ok := captureSeg(pattern, name[i:], append(captures[:n:n], name[:i])); ok {
+					return c, true
+				}
+				if i >= len(name) || name[i] == os.PathSeparator {
+					return captures, false
+				}
+				_, size := utf8.DecodeRuneInString(name[i:])
+				i += size
+			}
+
+		case '?', '[':
+			if len(name) == 0 || name[0] == os.PathSeparator {
+				return captures, false
+			}
+			r, size := utf8.DecodeRuneInString(name)
+
+			if pattern[0] == '?' {
+				pattern = pattern[1:]
+			} else {
+				var matched bool
+				var err error
+				if matched, pattern, err = matchClass(pattern[1:], r); !matched || err != nil {
+					return captures, false
+				}
+			}
+
+			captures = append(captures, name[:size])
+			name = name[size:]
+
+		default:
+			c, escaped, next := patternChar(pattern, 0)
+			if c == '\\' && escaping && !escaped { // The trailing '\\'
+				return captures, false
+			}
+
+			if len(name) == 0 || name[0] != c {
+				return captures, false
+			}
+			pattern, name = pattern[next:], name[1:]
+		}
+	}
+
+	return captures, len(name) == 0
+}
+
+// matchClass matches the rune against the character class (following the '['),
+// and returns the rest of the pattern following the class.
+// The class syntax is the same as filepath.Match's, filepath.ErrBadPattern is returned if it is malformed.
+//
+func matchClass(pattern string, r rune) (matched bool, rest string, err error) {
+	negated := false
+	if len(pattern) > 0 && pattern[0] == '^' {
+		negated = true
+		pattern = pattern[1:]
+	}
+
+	for nrange := 0; ; nrange++ {
+		if len(pattern) > 0 && pattern[0] == ']' && nrange > 0 {
+			pattern = pattern[1:]
+			break
+		}
+
+		var lo, hi rune
+		if lo, pattern, err = classChar(pattern); err != nil {
+			return
+		}
+		hi = lo
+		if len(pattern) > 0 && pattern[0] == '-' {
+			if hi, pattern, err = classChar(pattern[1:]); err != nil {
+				return
+			}
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return matched != negated, pattern, nil
+}
+
+func classChar(pattern string) (r rune, rest string, err error) {
+	if len(pattern) == 0 || pattern[0] == '-' || pattern[0] == ']' {
+		return 0, "", errBadPattern
+	}
+
+	if pattern[0] == '\\' && escaping {
+		pattern = pattern[1:]
+		if len(pattern) == 0 {
+			return 0, "", errBadPattern
+		}
+	}
+
+	r, size := utf8.DecodeRuneInString(pattern)
+	if r == utf8.RuneError && size == 1 {
+		return 0, "", errBadPattern
+	}
+	return r, pattern[size:], nil
+}
+
+// wildcardKind is the kind of a wildcard, which captures the text it matched.
+//
+type wildcardKind int
+
+const (
+	anyDirsWildcard wildcardKind = iota // '**'
+	starWildcard                        // '*'
+	charWildcard                        // '?' or '[...]'
+)
+
+// wildcardKinds returns the kinds of the wildcards of the segments, in the same order as their captures.
+//
+func wildcardKinds(segs []patternSeg) []wildcardKind {
+	var kinds []wildcardKind
+
+	for _, seg := range segs {
+		if seg.dirs < 0 {
+			kinds = append(kinds, anyDirsWildcard)
+			continue
+		}
+
+		pattern := seg.pattern
+		for i := 0; i < len(pattern); {
+			c, escaped, next := patternChar(pattern, i)
+
+			switch {
+			case escaped:
+			case c == '*':
+				for next < len(pattern) && pattern[next] == '*' {
+					next++
+				}
+				kinds = append(kinds, starWildcard)
+			case c == '?':
+				kinds = append(kinds, charWildcard)
+			case c == '[':
+				kinds = append(kinds, charWildcard)
+				if _, rest, err := matchClass(pattern[next:], 0); err == nil {
+					next = len(pattern) - len(rest)
+				}
+			}
+
+			i = next
+		}
+	}
+
+	return kinds
+}
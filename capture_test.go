@@ -0,0 +1,91 @@
+package expath
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+type captureTest struct {
+	pattern, s string
+	captures   []string
+	matched    bool
+}
+
+var captureTests = []captureTest{
+	{"src/**/*.proto", "src/a/b/c.proto", []string{"a/b", "c"}, true},
+	{"src/**/*.proto", "src/c.proto", []string{"", "c"}, true},
+	{"src/**/*.proto", "src/a/b/c.go", nil, false},
+	{"**/*.go", "/a/b.go", []string{"a", "b"}, true},
+	{"/**/abc", "/abc", []string{""}, true},
+	{"**/abc/**", "a/abc/c/d", []string{"a", "c/d"}, true},
+	{"abc/**", "abc", []string{""}, true},
+	{"abc/**/", "abc/a/b/", []string{"a/b"}, true},
+	{"**", "a/b/c", []string{"a/b/c"}, true},
+	{"/**", "/a/b/", []string{"a/b"}, true},
+
+	{"*/**", "a/b/c", []string{"a", "b/c"}, true},
+	{"*.*", "a.b.c", []string{"a", "b.c"}, true},
+	{"a?c/[xy]*", "abc/yes", []string{"b", "y", "es"}, true},
+	{"[^a-c]*/z", "dog/z", []string{"d", "og"}, true},
+	{"*", "", []string{""}, true},
+	{"abc", "abc", []string{}, true},
+	{"", "", nil, true},
+
+	{"**/a/**/b/*", "a/a/x/b/b/c", []string{"", "a/x/b", "c"}, true},
+	{"**/a/**/a/**/b", "x/a/a/y/a/b", []string{"x", "", "y/a"}, true},
+	{"**/*/c/*", "a/b/c/d", []string{"a", "b", "d"}, true},
+	{"a/*/**/*.txt", "a/b/c/d/e.txt", []string{"b", "c/d", "e"}, true},
+}
+
+var captureEscapedTests = []captureTest{
+	{`a\*/*`, "a*/b", []string{"b"}, true},
+	{`[\]]*`, "]x", []string{"]", "x"}, true},
+}
+
+func TestMatchCaptures(t *testing.T) {
+	tests := captureTests
+	if runtime.GOOS != "windows" {
+		tests = append(tests, captureEscapedTests...)
+	}
+
+	for _, tt := range tests {
+		captures, matched, err := MatchCaptures(tt.pattern, tt.s)
+		if matched != tt.matched || err != nil || !reflect.DeepEqual(captures, tt.captures) {
+			t.Errorf("MatchCaptures(%#q, %#q) = %q, %v, %q want %q, %v", tt.pattern, tt.s, captures, matched, errp(err), tt.captures, tt.matched)
+		}
+
+		p := MustCompile(tt.pattern)
+		captures, matched = p.MatchCaptures(tt.s)
+		if matched != tt.matched || !reflect.DeepEqual(captures, tt.captures) {
+			t.Errorf("Compile(%#q).MatchCaptures(%#q) = %q, %v want %q, %v", tt.pattern, tt.s, captures, matched, tt.captures, tt.matched)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	for _, tests := range [][]MatchTest{matchTests, matchTests0} {
+		for _, tt := range tests {
+			p, err := Compile(tt.pattern)
+			if err != nil {
+				t.Errorf("Compile(%#q) = %q", tt.pattern, errp(err))
+				continue
+			}
+
+			if matched := p.Match(tt.s); matched != tt.matched {
+				t.Errorf("Compile(%#q).Match(%#q) = %v want %v", tt.pattern, tt.s, matched, tt.matched)
+			}
+		}
+	}
+
+	badPatterns := []string{"[", "a/**/[", "a[]", "a[^]", "[a-", "[-a]", "a*[z", "**/x[a-b"}
+	if runtime.GOOS != "windows" {
+		badPatterns = append(badPatterns, `a\`, `[\`)
+	}
+
+	for _, pattern := range badPatterns {
+		if _, err := Compile(pattern); err != errBadPattern {
+			t.Errorf("Compile(%#q) = %q want %q", pattern, errp(err), errp(errBadPattern))
+		}
+	}
+}
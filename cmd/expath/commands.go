@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chinmobi/expath"
+)
+
+// runGlob prints the files matching any of the patterns, based on the root, in sorted order.
+//
+func runGlob(opts *options, patterns []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		matches, atRoot, err := expath.Glob(pattern, opts.root, expath.Output(expath.FullPath))
+		if err != nil {
+			return exitError, fmt.Errorf("%s: %v", pattern, err)
+		}
+
+		for _, name := range matches {
+			rel, err := filepath.Rel(atRoot, name)
+			if err != nil {
+				return exitError, err
+			}
+
+			included, err := opts.isIncluded(filepath.ToSlash(rel))
+			if err != nil {
+				return exitError, err
+			}
+
+			if !included || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	if err := writeNames(stdout, opts, names); err != nil {
+		return exitError, err
+	}
+	return statusOf(len(names) > 0), nil
+}
+
+// matchResult is the JSON form of a match command's result.
+//
+type matchResult struct {
+	Path    string `json:"path"`
+	Matched bool   `json:"matched"`
+}
+
+// runMatch reports whether each path read from stdin matches any of the patterns.
+//
+func runMatch(opts *options, patterns []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	results := []matchResult{}
+	all := true
+
+	err := readNames(stdin, opts, func(name string) error {
+		matched, err := opts.matchAny(patterns, name)
+		if err != nil {
+			return err
+		}
+
+		results = append(results, matchResult{name, matched})
+		all = all && matched
+		return nil
+	})
+	if err != nil {
+		return exitError, err
+	}
+
+	if opts.json {
+		err = writeJSON(stdout, results)
+	} else {
+		lines := make([]string, len(results))
+		for i, r := range results {
+			lines[i] = fmt.Sprintf("%t\t%s", r.Matched, r.Path)
+		}
+		err = writeNames(stdout, opts, lines)
+	}
+	if err != nil {
+		return exitError, err
+	}
+
+	return statusOf(all && len(results) > 0), nil
+}
+
+// runFilter prints the paths read from stdin that match any of the patterns.
+//
+func runFilter(opts *options, patterns []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	var names []string
+
+	err := readNames(stdin, opts, func(name string) error {
+		matched, err := opts.matchAny(patterns, name)
+		if matched {
+			names = append(names, name)
+		}
+		return err
+	})
+	if err != nil {
+		return exitError, err
+	}
+
+	if err = writeNames(stdout, opts, names); err != nil {
+		return exitError, err
+	}
+	return statusOf(len(names) > 0), nil
+}
+
+// explainResult is the JSON form of an explain command's result.
+//
+type explainResult struct {
+	Pattern  string          `json:"pattern"`
+	Segments []segmentResult `json:"segments"`
+}
+
+type segmentResult struct {
+	Pattern string `json:"pattern"`
+	Dirs    int    `json:"dirs"`
+	AnyDirs bool   `json:"anyDirs"`
+}
+
+// runExplain prints how each pattern is separated into segments,
+// or how each of the names (given by the -name flags) is matched against each pattern.
+//
+func runExplain(opts *options, patterns []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	if len(opts.names) > 0 {
+		return runExplainNames(opts, patterns, stdout)
+	}
+
+	results := make([]explainResult, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		segs, err := expath.Segments(pattern)
+		if err != nil {
+			return exitError, fmt.Errorf("%s: %v", pattern, err)
+		}
+		r := explainResult{pattern, make([]segmentResult, len(segs))}
+		for i, seg := range segs {
+			r.Segments[i] = segmentResult{seg.Pattern, seg.Dirs, seg.AnyDirs()}
+		}
+		results = append(results, r)
+	}
+
+	if opts.json {
+		if err := writeJSON(stdout, results); err != nil {
+			return exitError, err
+		}
+		return exitMatched, nil
+	}
+
+	var b strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&b, "pattern %q\n", r.Pattern)
+		for i, seg := range r.Segments {
+			if seg.AnyDirs {
+				fmt.Fprintf(&b, "\tsegment %d: %-16q any dirs\n", i, seg.Pattern)
+			} else {
+				fmt.Fprintf(&b, "\tsegment %d: %-16q %d dir(s)\n", i, seg.Pattern, seg.Dirs)
+			}
+		}
+	}
+
+	if _, err := io.WriteString(stdout, b.String()); err != nil {
+		return exitError, err
+	}
+	return exitMatched, nil
+}
+
+// runExplainNames prints the trace of matching each of the names against each pattern.
+//
+func runExplainNames(opts *options, patterns []string, stdout io.Writer) (int, error) {
+	results := make([]*expath.Explanation, 0, len(patterns)*len(opts.names))
+	all := true
+
+	for _, pattern := range patterns {
+		for _, name := range opts.names {
+			e, err := expath.Explain(pattern, name)
+			if err != nil {
+				return exitError, fmt.Errorf("%s: %v", pattern, err)
+			}
+			all = all && e.Matched
+			results = append(results, e)
+		}
+	}
+
+	if opts.json {
+		if err := writeJSON(stdout, results); err != nil {
+			return exitError, err
+		}
+		return statusOf(all), nil
+	}
+
+	var b strings.Builder
+	for _, e := range results {
+		b.WriteString(e.String())
+	}
+
+	if _, err := io.WriteString(stdout, b.String()); err != nil {
+		return exitError, err
+	}
+	return statusOf(all), nil
+}
+
+func statusOf(matched bool) int {
+	if matched {
+		return exitMatched
+	}
+	return exitNoMatch
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// readNames reads the paths from r, separated by lines or NULs, and calls fn for each non-empty path.
+//
+func readNames(r io.Reader, opts *options, fn func(name string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	if opts.null {
+		scanner.Split(scanNulls)
+	}
+
+	for scanner.Scan() {
+		name := scanner.Text()
+		if !opts.null {
+			name = strings.TrimSuffix(name, "\r")
+		}
+		if len(name) == 0 {
+			continue
+		}
+
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// scanNulls is a bufio.SplitFunc that splits the input by NULs.
+//
+func scanNulls(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// writeNames writes the names to w, terminated by newlines or NULs, or as a JSON array.
+//
+func writeNames(w io.Writer, opts *options, names []string) error {
+	if opts.json {
+		if names == nil {
+			names = []string{}
+		}
+		return writeJSON(w, names)
+	}
+
+	term := byte('\n')
+	if opts.null {
+		term = 0
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		bw.WriteString(name)
+		bw.WriteByte(term)
+	}
+	return bw.Flush()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
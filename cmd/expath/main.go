@@ -0,0 +1,98 @@
+// Command expath globs and matches file paths with the expath patterns,
+// which extend the standard library path/filepath's patterns with the any-dirs' term
+// ('**' matches zero or more directories in a path).
+//
+// Usage:
+//
+//	expath glob [flags] PATTERN...            print the files matching any pattern
+//	expath match [flags] PATTERN... < paths   report whether each path read from stdin matches
+//	expath filter [flags] PATTERN... < paths  print the paths read from stdin that match
+//	expath explain [flags] PATTERN...         print how each pattern is separated into segments,
+//	                                          or with -name, how each name is matched
+//
+// Flags (may be given before or after the patterns):
+//
+//	-root DIR      the root directory that the glob patterns are based on (glob only)
+//	-name PATH     explain how PATH is matched, may be repeated (explain only)
+//	-x PATTERN     exclude the paths matching PATTERN, may be repeated (alias -exclude)
+//	-0             read and write NUL-separated paths instead of lines (alias -null)
+//	-json          write the results as JSON
+//
+// The exit status is 0 if any path is printed (for match: if every path matches),
+// 1 if nothing matches, and 2 if an error occurred.
+//
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitMatched = 0
+	exitNoMatch = 1
+	exitError   = 2
+)
+
+const usage = `usage:
+	expath glob [flags] PATTERN...
+	expath match [flags] PATTERN... < paths
+	expath filter [flags] PATTERN... < paths
+	expath explain [flags] PATTERN...
+
+flags:
+	-root DIR      the root directory that the glob patterns are based on (glob only)
+	-name PATH     explain how PATH is matched, may be repeated (explain only)
+	-x PATTERN     exclude the paths matching PATTERN, may be repeated (alias -exclude)
+	-0             read and write NUL-separated paths instead of lines (alias -null)
+	-json          write the results as JSON
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with the given arguments (without the program name), and returns the exit status.
+//
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+
+	var cmd func(*options, []string, io.Reader, io.Writer) (int, error)
+
+	switch args[0] {
+	case "glob":
+		cmd = runGlob
+	case "match":
+		cmd = runMatch
+	case "filter":
+		cmd = runFilter
+	case "explain":
+		cmd = runExplain
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitMatched
+	default:
+		fmt.Fprintf(stderr, "expath: unknown command %q\n\n%s", args[0], usage)
+		return exitError
+	}
+
+	opts, patterns, err := parseOptions(args[0], args[1:], stderr)
+	if err != nil {
+		return exitError
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintf(stderr, "expath %s: missing PATTERN\n\n%s", args[0], usage)
+		return exitError
+	}
+
+	status, err := cmd(opts, patterns, stdin, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "expath %s: %v\n", args[0], err)
+		return exitError
+	}
+	return status
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type runTest struct {
+	args   []string
+	stdin  string
+	stdout string
+	status int
+}
+
+func TestRun(t *testing.T) {
+	root := "testdata" // a/b/c.go, a/d.go, a/e.txt and vendor/f.go
+
+	tests := []runTest{
+		{[]string{"glob", "-root", root, "-x", "vendor/**", "**/*.go"}, "",
+			"a/b/c.go\na/d.go\n", exitMatched},
+		{[]string{"glob", "**/*.go", "-root", root, "--exclude", "**/d.go"}, "",
+			"a/b/c.go\nvendor/f.go\n", exitMatched},
+		{[]string{"glob", "-root", root, "-0", "a/*.txt", "a/*.go", "a/d.*"}, "",
+			"a/d.go\x00a/e.txt\x00", exitMatched},
+		{[]string{"glob", "-root", root, "-json", "**/*.md"}, "",
+			"[]\n", exitNoMatch},
+
+		{[]string{"match", "src/**/*.go"}, "src/a.go\nsrc/a/b.go\n",
+			"true\tsrc/a.go\ntrue\tsrc/a/b.go\n", exitMatched},
+		{[]string{"match", "src/**/*.go"}, "src/a.go\r\nsrc/a.txt\r\n",
+			"true\tsrc/a.go\nfalse\tsrc/a.txt\n", exitNoMatch},
+		{[]string{"match", "-json", "*.go"}, "a.go",
+			"[\n  {\n    \"path\": \"a.go\",\n    \"matched\": true\n  }\n]\n", exitMatched},
+
+		{[]string{"filter", "**/*.go", "-x", "vendor/**"}, "a.go\nb.txt\nvendor/c.go\nd/e.go\n",
+			"a.go\nd/e.go\n", exitMatched},
+		{[]string{"filter", "-0", "**/*.go"}, "a b.go\x00c\nd.go\x00e.txt",
+			"a b.go\x00c\nd.go\x00", exitMatched},
+		{[]string{"filter", "*.go"}, "a.txt\n",
+			"", exitNoMatch},
+		{[]string{"filter", "[", "--", "-x"}, "a\n",
+			"", exitError},
+
+		{[]string{"explain", "src/**/*.go"}, "",
+			"pattern \"src/**/*.go\"\n" +
+				"\tsegment 0: \"src/\"           1 dir(s)\n" +
+				"\tsegment 1: \"**/\"            any dirs\n" +
+				"\tsegment 2: \"*.go\"           1 dir(s)\n", exitMatched},
+		{[]string{"explain", "src/**/test/*.go", "-name", "src/pkg/tests/a.go"}, "",
+			"pattern \"src/**/test/*.go\", name \"src/pkg/tests/a.go\": not matched\n" +
+				"\tsegment 0: \"src/\"           1 dir(s)\n" +
+				"\tsegment 1: \"**/\"            any dirs\n" +
+				"\tsegment 2: \"test/*.go\"      2 dir(s)\n" +
+				"\ttry segment 0 \"src/\" on \"src/\": matched\n" +
+				"\ttry segment 2 \"test/*.go\" on \"pkg/tests/\" (** took \"\"): not matched\n" +
+				"\ttry segment 2 \"test/*.go\" on \"tests/a.go\" (** took \"pkg/\"): not matched\n" +
+				"\tfailed at segment 2, offset 8: \"test\" doesn't match \"tests\"\n", exitNoMatch},
+
+		{[]string{"glob"}, "", "", exitError},
+		{[]string{"unknown", "*"}, "", "", exitError},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+
+		status := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		out := filepath.ToSlash(strings.ReplaceAll(stdout.String(), root+string(filepath.Separator), ""))
+		if status != tt.status || out != tt.stdout {
+			t.Errorf("run(%q) = %d, %q want %d, %q (stderr: %q)",
+				tt.args, status, out, tt.status, tt.stdout, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/chinmobi/expath"
+)
+
+// options holds the flags of a command.
+//
+type options struct {
+	root     string
+	excludes patternsFlag
+	names    patternsFlag
+	null     bool
+	json     bool
+}
+
+// patternsFlag implements the flag.Value interface to collect a repeated pattern (or path) flag.
+//
+type patternsFlag []string
+
+func (p *patternsFlag) String() string {
+	return strings.Join(*p, " ")
+}
+
+func (p *patternsFlag) Set(pattern string) error {
+	*p = append(*p, pattern)
+	return nil
+}
+
+// parseOptions parses the flags of the named command, which may be interleaved with the patterns.
+//
+func parseOptions(name string, args []string, stderr io.Writer) (*options, []string, error) {
+	opts := new(options)
+
+	flags := flag.NewFlagSet("expath "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	if name == "glob" {
+		flags.StringVar(&opts.root, "root", "", "the root `DIR` that the patterns are based on")
+	}
+	if name == "explain" {
+		flags.Var(&opts.names, "name", "explain how `PATH` is matched, may be repeated")
+	}
+	flags.Var(&opts.excludes, "x", "exclude the paths matching `PATTERN`")
+	flags.Var(&opts.excludes, "exclude", "exclude the paths matching `PATTERN`")
+	flags.BoolVar(&opts.null, "0", false, "read and write NUL-separated paths")
+	flags.BoolVar(&opts.null, "null", false, "read and write NUL-separated paths")
+	flags.BoolVar(&opts.json, "json", false, "write the results as JSON")
+
+	var patterns []string
+
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return nil, nil, err
+		}
+
+		rest := flags.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			patterns = append(patterns, rest...)
+			break
+		}
+
+		if len(rest) > 0 {
+			patterns = append(patterns, rest[0])
+			rest = rest[1:]
+		}
+		args = rest
+	}
+
+	return opts, patterns, nil
+}
+
+// matchAny reports whether name matches any of the patterns and none of the excludes.
+//
+func (o *options) matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := expath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return o.isIncluded(name)
+		}
+	}
+	return false, nil
+}
+
+// isIncluded reports whether name matches none of the excludes.
+//
+func (o *options) isIncluded(name string) (bool, error) {
+	for _, exclude := range o.excludes {
+		matched, err := expath.Match(exclude, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package expath
+
+// Pattern is a compiled pattern, that is scanned into segments and checked once,
+// and then used to match or glob repeatedly.
+// A Pattern is safe for concurrent use by multiple goroutines.
+//
+type Pattern struct {
+	pattern string
+	segs    []patternSeg // of the pattern cleaned of the dot dirs
+	literal []patternSeg // of the pattern as written, if it has the dot dirs
+}
+
+// Compile parses the pattern, filepath.ErrBadPattern is returned if it is malformed.
+// The syntax of patterns is the same as in Match.
+//
+func Compile(pattern string) (*Pattern, error) {
+	if err := validatePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	segs, literal, err := dotsSegments(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pattern{pattern, segs, literal}, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is malformed.
+//
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic(`expath: Compile(` + pattern + `): ` + err.Error())
+	}
+	return p
+}
+
+// String returns the source text of the pattern.
+//
+func (p *Pattern) String() string {
+	return p.pattern
+}
+
+// Match reports whether name matches the pattern, the same as the Match function.
+//
+func (p *Pattern) Match(name string) bool {
+	matched, _ := matchDots(p.segs, p.literal, name)
+	return matched
+}
+
+// CanMatchUnder reports whether any path under the dir (the dir and a separator, followed by more)
+// could match the pattern, so a walker can skip the dir (such as returning fs.SkipDir) if it can't.
+// The dir is in the same form as the names to match, the empty dir or "." is the top of the walk.
+//
+// The answer is exact except for the dirs within the leading dirs of the pattern (before the first '**'),
+// which is true if they match the leading dirs, even though the rest of the pattern may never match.
+//
+func (p *Pattern) CanMatchUnder(dir string) bool {
+	return canMatchUnder(p.segs, dir) || (p.literal != nil && canMatchUnder(p.literal, dir))
+}
+
+// MatchCaptures reports whether name matches the pattern, and returns the text matched by each wildcard,
+// the same as the MatchCaptures function.
+//
+func (p *Pattern) MatchCaptures(name string) (captures []string, matched bool) {
+	captures, matched, _ = matchCapturesDots(p.segs, p.literal, name)
+	return
+}
+
+// Glob returns the names of all files matching the pattern, the same as the Glob function.
+//
+func (p *Pattern) Glob(root string, opts ...GlobOption) (matches []string, atRoot string, err error) {
+	return Glob(p.pattern, root, opts...)
+}
+
+// GlobFn uses the GlobFunc callback function to handle each matched file name or encountered file error,
+// the same as the GlobFn function.
+//
+func (p *Pattern) GlobFn(root string, globFn GlobFunc, opts ...GlobOption) error {
+	return GlobFn(p.pattern, root, globFn, opts...)
+}
@@ -0,0 +1,139 @@
+package expath
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// ErrEscapesRoot is the error of the Confined option, for a pattern or a symbolic link that leads out of the root.
+// It is wrapped in the GlobError, use errors.Is to check it.
+//
+var ErrEscapesRoot = errors.New("escapes the root")
+
+// Confined returns a GlobOption that confines the glob to the root, as a policy for the untrusted patterns:
+//
+// The pattern with a volume name, or the leading term expanded to an absolute path by the ExpandPattern option
+// (that replaces the root), or with the '..' dirs leading out of the root
+// (such as "../../etc/**" or "a/../../b"), is rejected as a whole. The leading '/' is still based on the root.
+//
+// The dirs and the matched files that are (or are under) symbolic links resolved out of the root,
+// or can't be resolved, are reported as errors instead of being followed or matched,
+// so they can be collected by the ContinueOnError option. It costs resolving the real path of each dir.
+//
+// Both errors are the GlobErrors with the ErrEscapesRoot.
+//
+func Confined() GlobOption {
+	return func(o *globOptions) {
+		o.confined = true
+	}
+}
+
+// confinePattern checks that the pattern doesn't lead out of the root lexically.
+// The any-dirs' term may match no dirs, so it doesn't count.
+//
+func confinePattern(pattern string) error {
+	if filepath.VolumeName(pattern) != "" {
+		return ErrEscapesRoot
+	}
+
+	depth := 0
+	for _, dir := range splitDirs(pattern, true) {
+		switch dir {
+		case ".", "**":
+		case "..":
+			if depth--; depth < 0 {
+				return ErrEscapesRoot
+			}
+		default:
+			depth++
+		}
+	}
+	return nil
+}
+
+// confinedPathHelper wraps a pathHelper to refuse reading the dirs resolved out of the root.
+//
+type confinedPathHelper struct {
+	pathHelper
+	root     string
+	realRoot string
+}
+
+// isConfined reports whether the real path of the named file is the root or under it.
+//
+func (h *confinedPathHelper) isConfined(name string) bool {
+	if h.realRoot == "" {
+		root, err := h.pathHelper.realPath(h.root)
+		if err != nil {
+			return false
+		}
+		h.realRoot = root
+	}
+
+	real, err := h.pathHelper.realPath(name)
+	if err != nil {
+		return false
+	}
+
+	if !strings.HasPrefix(real, h.realRoot) {
+		return false
+	}
+	n := len(h.realRoot)
+	return len(real) == n || isDirSeparator(h.realRoot, n-1) || isDirSeparator(real, n)
+}
+
+func (h *confinedPathHelper) getEntries(dir string) ([]fs.DirEntry, error) {
+	entries, err := h.pathHelper.getEntries(dir)
+	if err != nil || entries == nil {
+		return entries, err
+	}
+
+	if !h.isConfined(dir) {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: ErrEscapesRoot}
+	}
+	return entries, nil
+}
+
+// getEntry refuses the named file whose parent dir is resolved out of the root,
+// the file itself is checked when it is matched.
+//
+func (h *confinedPathHelper) getEntry(name string) (fs.DirEntry, error) {
+	entry, err := h.pathHelper.getEntry(name)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+
+	if !h.isConfined(filepath.Dir(name)) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: ErrEscapesRoot}
+	}
+	return entry, nil
+}
+
+// confinedHandler wraps a matchesHandler to report the matched symbolic links resolved out of the root as errors.
+//
+type confinedHandler struct {
+	matchesHandler
+	helper *confinedPathHelper
+}
+
+func (c *confinedHandler) onMatched(matched string, entry fs.DirEntry) error {
+	name := appendDirPath(c.helper.root, "", matched)
+
+	if entry == nil {
+		if e, err := c.helper.pathHelper.getEntry(name); err == nil && e != nil {
+			entry = e
+		}
+	}
+
+	if entry != nil && entry.Type()&fs.ModeSymlink != 0 && !c.helper.isConfined(name) {
+		return c.matchesHandler.onError(newGlobError(OpConfine, matched, name, ErrEscapesRoot))
+	}
+	return c.matchesHandler.onMatched(matched, entry)
+}
+
+func (c *confinedHandler) setRoot(root string) error {
+	c.helper.root = root
+	return c.matchesHandler.setRoot(root)
+}
@@ -0,0 +1,89 @@
+package expath
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestConfinePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		escapes bool
+	}{
+		{"**/*.go", false},
+		{"/etc/passwd", false},
+		{"./a/**", false},
+		{"a/../b/*", false},
+		{"../**", true},
+		{"./../a", true},
+		{"a/../../b", true},
+		{"a/**/../..", true},
+		{"/../a", true},
+	}
+
+	for _, tt := range tests {
+		if err := confinePattern(tt.pattern); (err != nil) != tt.escapes {
+			t.Errorf("confinePattern(%#q) = %v want escapes %v", tt.pattern, err, tt.escapes)
+		}
+	}
+}
+
+func TestConfined(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+
+	for _, name := range []string{"secret/a.txt", "root/in/b.txt", "root/c.txt"} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"out":      filepath.Join(dir, "secret"),
+		"out.txt":  filepath.Join(dir, "secret", "a.txt"),
+		"in/up":    "..",
+		"in.txt":   filepath.Join("in", "b.txt"),
+		"dead.txt": "nothing",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Skip("symlink:", err)
+		}
+	}
+
+	for _, pattern := range []string{"../secret/*", "a/../../secret/a.txt"} {
+		_, _, err := Glob(pattern, root, Confined())
+		var ge *GlobError
+		if !errors.As(err, &ge) || ge.Op != OpConfine || !errors.Is(err, ErrEscapesRoot) {
+			t.Errorf("Glob(%#q) error = %v want %v", pattern, err, ErrEscapesRoot)
+		}
+	}
+
+	matches, _, err := Glob("**/*.txt", root, Confined(), ContinueOnError())
+	sort.Strings(matches)
+	if want := []string{"c.txt", "in.txt", "in/b.txt"}; !reflect.DeepEqual(matches, want) {
+		t.Errorf("Glob() = %q want %q", matches, want)
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("Glob() error = %v want 3 errors", err)
+	}
+	for _, e := range errs {
+		if !errors.Is(e, ErrEscapesRoot) {
+			t.Errorf("Glob() error = %v want %v", e, ErrEscapesRoot)
+		}
+	}
+
+	if _, _, err = Glob("out/a.txt", root, Confined()); !errors.Is(err, ErrEscapesRoot) {
+		t.Errorf("Glob(`out/a.txt`) error = %v want %v", err, ErrEscapesRoot)
+	}
+}
@@ -0,0 +1,225 @@
+package expath
+
+import (
+	"container/list"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DirCache caches the dir listings (the entries with their types) across the Glob calls, passed by the Cache option.
+// By default, a cached listing is used only if the modification time of the dir is unchanged,
+// which costs a Stat of the dir instead of reading it. Invalidate drops the listing explicitly.
+// The cached listings are bounded by the number of their entries, the least recently used ones are dropped first.
+// A DirCache is safe for concurrent use by multiple goroutines.
+//
+type DirCache struct {
+	maxEntries   int
+	checkModTime bool
+
+	mu   sync.Mutex
+	dirs map[string]*list.Element // of *cachedDir, keyed by the cleaned dir
+	lru  list.List                // the front is the most recently used
+	size int                      // the number of the cached entries, plus one for each dir
+}
+
+type cachedDir struct {
+	dir     string
+	entries []fs.DirEntry // sorted by name
+	modTime time.Time
+}
+
+// A DirCacheOption configures the DirCache.
+//
+type DirCacheOption func(*DirCache)
+
+// DefaultMaxCachedEntries is the default bound of the number of the entries cached by a DirCache.
+//
+const DefaultMaxCachedEntries = 1 << 16
+
+// MaxCachedEntries returns a DirCacheOption that bounds the number of the entries cached (of all the dirs),
+// n <= 0 for no bound.
+//
+func MaxCachedEntries(n int) DirCacheOption {
+	return func(c *DirCache) {
+		c.maxEntries = n
+	}
+}
+
+// NoModTimeCheck returns a DirCacheOption that uses the cached listings without checking the modification time
+// of the dirs, so the changes of the dirs are seen only after Invalidate (or Clear).
+//
+func NoModTimeCheck() DirCacheOption {
+	return func(c *DirCache) {
+		c.checkModTime = false
+	}
+}
+
+// NewDirCache returns a new DirCache configured by the options.
+//
+func NewDirCache(opts ...DirCacheOption) *DirCache {
+	c := &DirCache{
+		maxEntries:   DefaultMaxCachedEntries,
+		checkModTime: true,
+		dirs:         make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Cache returns a GlobOption that reads the dirs through the DirCache.
+//
+func Cache(c *DirCache) GlobOption {
+	return func(o *globOptions) {
+		o.cache = c
+	}
+}
+
+// Invalidate drops the cached listing of the dir.
+//
+func (c *DirCache) Invalidate(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.dirs[filepath.Clean(dir)]; ok {
+		c.remove(el)
+	}
+}
+
+// Clear drops all the cached listings.
+//
+func (c *DirCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dirs = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+}
+
+// Len returns the number of the cached dirs.
+//
+func (c *DirCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.dirs)
+}
+
+func (c *DirCache) remove(el *list.Element) {
+	cd := c.lru.Remove(el).(*cachedDir)
+	delete(c.dirs, cd.dir)
+	c.size -= len(cd.entries) + 1
+}
+
+// get returns the cached entries of the dir, that is still valid for the modification time.
+//
+func (c *DirCache) get(dir string, modTime time.Time) ([]fs.DirEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.dirs[dir]
+	if !ok {
+		return nil, false
+	}
+
+	cd := el.Value.(*cachedDir)
+	if c.checkModTime && !cd.modTime.Equal(modTime) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return cd.entries, true
+}
+
+func (c *DirCache) put(dir string, entries []fs.DirEntry, modTime time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.dirs[dir]; ok {
+		c.remove(el)
+	}
+
+	n := len(entries) + 1
+	if c.maxEntries > 0 && n > c.maxEntries {
+		return
+	}
+
+	c.dirs[dir] = c.lru.PushFront(&cachedDir{dir, entries, modTime})
+	c.size += n
+
+	for c.maxEntries > 0 && c.size > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// cachedPathHelper implements the pathHelper interface by reading the dirs through the DirCache.
+//
+type cachedPathHelper struct {
+	pathHelper
+	cache *DirCache
+}
+
+func (h *cachedPathHelper) getEntries(dir string) ([]fs.DirEntry, error) {
+	c, key := h.cache, filepath.Clean(dir)
+
+	var modTime time.Time
+	if c.checkModTime {
+		fi, err := os.Stat(dir)
+		if err != nil { // Leave it to the pathHelper
+			c.Invalidate(key)
+			return h.pathHelper.getEntries(dir)
+		}
+		modTime = fi.ModTime()
+	}
+
+	if entries, ok := c.get(key, modTime); ok {
+		return entries, nil
+	}
+
+	entries, err := h.pathHelper.getEntries(dir)
+	if err != nil || entries == nil {
+		return entries, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	c.put(key, entries, modTime)
+
+	return entries, nil
+}
+
+// getEntry looks up the entry in the cached listing of its parent dir, if the cache doesn't check the modification time
+// (otherwise checking the parent dir costs the same as getting the entry directly).
+//
+func (h *cachedPathHelper) getEntry(name string) (fs.DirEntry, error) {
+	c := h.cache
+	if c.checkModTime {
+		return h.pathHelper.getEntry(name)
+	}
+
+	dir, base := filepath.Dir(name), filepath.Base(name)
+	if base == "." || base == ".." || base == string(filepath.Separator) {
+		return h.pathHelper.getEntry(name)
+	}
+
+	entries, ok := c.get(dir, time.Time{})
+	if !ok {
+		return h.pathHelper.getEntry(name)
+	}
+
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name() >= base
+	})
+	if i < len(entries) && entries[i].Name() == base {
+		return entries[i], nil
+	}
+	return nil, nil
+}
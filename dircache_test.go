@@ -0,0 +1,108 @@
+package expath
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func cachedGlob(t *testing.T, pattern, root string, cache *DirCache) ([]string, int) {
+	var helper countingPathHelper
+	var mh matchedSet
+
+	if err := newGlobOptions([]GlobOption{Cache(cache)}).glob(pattern, root, &helper, &mh); err != nil {
+		t.Fatalf("Glob(%#q) error: %v", pattern, err)
+	}
+
+	sort.Strings(mh.matches)
+	return mh.matches, helper.calls
+}
+
+func touchDir(t *testing.T, dir string) {
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(dir, future, future); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirCache(t *testing.T) {
+	root := makeTestTree(t, "a/b/c.go", "a/d.go", "e/f.go", "e/g/h.txt")
+	cache := NewDirCache()
+
+	matches, calls := cachedGlob(t, "**/*.go", root, cache)
+	if want := []string{"a/b/c.go", "a/d.go", "e/f.go"}; !reflect.DeepEqual(matches, want) || calls == 0 {
+		t.Fatalf("Glob() = %q, %d calls want %q", matches, calls, want)
+	}
+
+	if matches, calls = cachedGlob(t, "**/*.txt", root, cache); calls != 0 || len(matches) != 1 {
+		t.Errorf("Glob() again = %q, %d calls want 1 match and no calls", matches, calls)
+	}
+
+	// A new file changes the modification time of its dir.
+	if err := os.WriteFile(filepath.Join(root, "e", "g", "i.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	touchDir(t, filepath.Join(root, "e", "g"))
+
+	matches, calls = cachedGlob(t, "**/*.go", root, cache)
+	if want := []string{"a/b/c.go", "a/d.go", "e/f.go", "e/g/i.go"}; !reflect.DeepEqual(matches, want) || calls != 1 {
+		t.Errorf("Glob() after the change = %q, %d calls want %q, 1 call", matches, calls, want)
+	}
+}
+
+func TestDirCacheInvalidate(t *testing.T) {
+	root := makeTestTree(t, "a/b.go", "a/c/d.go")
+	cache := NewDirCache(NoModTimeCheck())
+
+	cachedGlob(t, "**/*.go", root, cache)
+
+	if err := os.WriteFile(filepath.Join(root, "a", "e.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	touchDir(t, filepath.Join(root, "a"))
+
+	if matches, _ := cachedGlob(t, "a/*.go", root, cache); len(matches) != 1 {
+		t.Errorf("Glob() before Invalidate = %q want the stale listing", matches)
+	}
+	if matches, calls := cachedGlob(t, "a/c/d.go", root, cache); len(matches) != 1 || calls != 0 {
+		t.Errorf("Glob(literal) = %q, %d calls want 1 match and no calls", matches, calls)
+	}
+
+	cache.Invalidate(filepath.Join(root, "a") + string(filepath.Separator))
+
+	if matches, _ := cachedGlob(t, "a/*.go", root, cache); len(matches) != 2 {
+		t.Errorf("Glob() after Invalidate = %q want 2 matches", matches)
+	}
+
+	cache.Clear()
+	if n := cache.Len(); n != 0 {
+		t.Errorf("Len() after Clear = %d want 0", n)
+	}
+}
+
+func TestDirCacheBounds(t *testing.T) {
+	root := makeTestTree(t, "a/1", "a/2", "b/1", "b/2", "c/1", "c/2")
+	cache := NewDirCache(MaxCachedEntries(7))
+
+	cachedGlob(t, "**", root, cache)
+	if n := cache.Len(); n != 2 {
+		t.Errorf("Len() = %d want 2 (the root of 4 entries and one of the dirs of 3)", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matches, _, err := Glob("**", root, Cache(cache))
+			if err != nil || len(matches) != 6 {
+				t.Errorf("Glob() = %q, %v want 6 matches", matches, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
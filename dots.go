@@ -0,0 +1,279 @@
+package expath
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// The '.' and '..' dirs of the patterns:
+//
+// Match cleans the pattern lexically (as path.Clean does), so `a/./b/*` and `src/*/../shared/*` match
+// "a/b/c" and "src/shared/c" as `a/b/*` and `src/shared/*` do. The name is never cleaned, but it also matches
+// the pattern as written, where the dot dirs match themselves, such as "a/./b/c" and "src/x/../shared/c".
+//
+// Glob resolves them by the file system by default (as the shell does), each matched path keeps the dot dirs
+// (such as "src/x/../shared/a.go" and "src/y/../shared/a.go"), which match the pattern as written.
+// The LexicalDots option cleans the pattern before the glob instead, the paths matched match the cleaned one.
+//
+// The escaped dots (such as `\.`) are literal, not the dot dirs.
+//
+// A '..' following the any-dirs' term (such as `**/../a`) is malformed, since the dir it leaves is undetermined.
+// The leading dot dirs of the pattern are moved into the root by Glob, as before.
+
+// LexicalDots returns a GlobOption that cleans the '.' and '..' dirs of the pattern lexically before the glob,
+// instead of resolving them by the file system, so no symbolic link is followed back by a '..'.
+//
+func LexicalDots() GlobOption {
+	return func(o *globOptions) {
+		o.lexicalDots = true
+	}
+}
+
+// cleanDots removes the '.' dirs and the '..' dirs with their preceding dirs of the path (a pattern or a name).
+// The path without the dot dirs is returned as is, otherwise it's rebuilt as path.Clean does,
+// but the trailing separator is kept, which is significant to the patterns.
+// For the pattern, errBadPattern is returned if a '..' follows the any-dirs' term.
+//
+func cleanDots(path string, isPattern bool) (string, error) {
+	if isPattern {
+		path = plainSeparators(path)
+	}
+	if !hasDotDirs(path, isPattern) {
+		return path, nil
+	}
+
+	vol := filepath.VolumeName(path)
+	path = path[len(vol):]
+
+	nLen := len(path)
+	rooted := isDirSeparator(path, 0)
+	trailing := isDirSeparator(path, nLen-1)
+
+	var dirs []string
+	for _, dir := range splitDirs(path, isPattern) {
+		switch dir {
+		case "", ".":
+		case "..":
+			n := len(dirs)
+			switch {
+			case n > 0 && dirs[n-1] == "**" && isPattern:
+				return "", errBadPattern
+			case n > 0 && dirs[n-1] != "..":
+				dirs = dirs[:n-1]
+			case rooted: // The parent of the root is the root
+			default:
+				dirs = append(dirs, dir)
+			}
+		default:
+			dirs = append(dirs, dir)
+		}
+	}
+
+	cleaned := strings.Join(dirs, "/")
+	switch {
+	case rooted:
+		cleaned = "/" + cleaned
+	case cleaned == "":
+		cleaned = "."
+	}
+	if trailing && len(dirs) > 0 {
+		cleaned += "/"
+	}
+
+	return vol + filepath.FromSlash(cleaned), nil
+}
+
+// hasDotDirs reports whether the path (a pattern or a name) has any '.' or '..' dir.
+//
+func hasDotDirs(path string, isPattern bool) bool {
+	if strings.IndexByte(path, '.') < 0 {
+		return false
+	}
+	for _, dir := range splitDirs(path, isPattern) {
+		if dir == "." || dir == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDotDirs checks that no '..' follows the any-dirs' term of the pattern (after cleaning).
+//
+func validateDotDirs(pattern string) error {
+	_, err := cleanDots(pattern, true)
+	return err
+}
+
+// splitDirs splits the path (a pattern or a name) into the dirs by the Separators.
+// The pattern is walked by patternChar, so its escaped Separators split it as the plain ones,
+// and each dir is in the text of the pattern (an escaped dot stays escaped).
+//
+func splitDirs(path string, isPattern bool) []string {
+	if !isPattern {
+		return strings.FieldsFunc(path, func(r rune) bool {
+			return r < utf8.RuneSelf && isSeparatorChar(byte(r))
+		})
+	}
+
+	var dirs []string
+	from := 0
+	for i := 0; i < len(path); {
+		c, _, next := patternChar(path, i)
+		if isSeparatorChar(c) {
+			if from < i {
+				dirs = append(dirs, path[from:i])
+			}
+			from = next
+		}
+		i = next
+	}
+	if from < len(path) {
+		dirs = append(dirs, path[from:])
+	}
+	return dirs
+}
+
+// dotsSegments scans the pattern into the segments of it cleaned of the dot dirs,
+// and the ones of it as written if it has any dot dir (nil otherwise).
+//
+func dotsSegments(pattern string) (segs, literal []patternSeg, err error) {
+	cleaned, err := cleanDots(pattern, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if segs, err = scanSegments(cleaned); err != nil {
+		return nil, nil, err
+	}
+	if hasDotDirs(pattern, true) {
+		literal, err = scanSegments(pattern)
+	}
+	return segs, literal, err
+}
+
+// matchDots matches the name against the cleaned segments, and then against the literal ones if any.
+//
+func matchDots(segs, literal []patternSeg, name string) (bool, error) {
+	matched, err := matchPattern(segs, name)
+	if !matched && err == nil && literal != nil {
+		matched, err = matchPattern(literal, name)
+	}
+	return matched, err
+}
+
+// matchCapturesDots is matchDots with the captures of the cleaned segments' wildcards. The name matched
+// only by the literal segments is cleaned to be captured, since the dot dirs of both are at the same dirs.
+//
+func matchCapturesDots(segs, literal []patternSeg, name string) (captures []string, matched bool, err error) {
+	captures, matched, err = matchCaptures(segs, name)
+	if !matched && err == nil && literal != nil {
+		if matched, err = matchPattern(literal, name); matched {
+			name, _ = cleanDots(name, false)
+			captures, matched, err = matchCaptures(segs, name)
+		}
+	}
+	return captures, matched, err
+}
+
+// splitDotsSeg splits the normal pattern segment (following the any-dirs' segment) at its first dot dir,
+// the pre part (maybe empty) is matched at any depth by the any-dirs' term, and the post part (beginning with
+// the dot dir) is globbed from each dir matched by the pre part.
+//
+func splitDotsSeg(seg patternSeg) (pre, post patternSeg, ok bool) {
+	if !hasDotDirs(seg.pattern, true) {
+		return
+	}
+
+	i, dirs := 0, 0
+	for nLen := len(seg.pattern); i < nLen; dirs++ {
+		to, _ := scanDirs(seg.pattern, i, nLen, 1)
+		if dir := trimPath(seg.pattern[i:to]); dir == "." || dir == ".." {
+			break
+		}
+		i = to
+	}
+
+	pre = patternSeg{trimPath(seg.pattern[:i]), dirs}
+	post = patternSeg{seg.pattern[i:], seg.dirs - dirs}
+	return pre, post, true
+}
+
+// exglobSeg globs the normal pattern segment that following the any-dirs' pattern segment, from the dir
+// where the any-dirs' term begins. The segment that has the dot dirs is split to be resolved by the file system.
+//
+func exglobSeg(segs []patternSeg, curr int,
+	dir, matchedPath string,
+	helper pathHelper, matches matchesHandler) error {
+
+	pre, post, ok := splitDotsSeg(segs[curr])
+	if !ok {
+		return exglob(segs, curr, dir, matchedPath, len(matchedPath), 0, new(dirStack), helper, matches)
+	}
+
+	rest := append([]patternSeg{post}, segs[curr+1:]...)
+
+	if pre.dirs == 0 {
+		return dotsWalk(dir, matchedPath, nil, rest, new(dirStack), helper, matches)
+	}
+
+	preSegs := append(segs[:curr:curr], pre)
+	dm := &dotsMatches{matchesHandler: matches, dir: dir, matchedPath: matchedPath, rest: rest, helper: helper}
+	return exglob(preSegs, curr, dir, matchedPath, len(matchedPath), 0, new(dirStack), helper, dm)
+}
+
+// dotsMatches handles each dir matched by the pre part of the split segment, by globbing the rest segments from it.
+//
+type dotsMatches struct {
+	matchesHandler
+	dir, matchedPath string
+	rest             []patternSeg
+	helper           pathHelper
+}
+
+func (m *dotsMatches) onMatched(matched string, entry fs.DirEntry) error {
+	if !mayBeDir(entry) {
+		return nil
+	}
+	d := appendDirPath(m.dir, m.matchedPath, matched)
+	return segsGlob(m.rest, 0, d, matched, m.helper, m.matchesHandler)
+}
+
+// dotsWalk globs the rest segments from the dir and each of its sub dirs, for the split segment that begins with
+// the dot dir. The stack has the dirs being walked (excluding the dir), to skip the cycles.
+//
+func dotsWalk(dir, matchedPath string, entry fs.DirEntry,
+	rest []patternSeg, stack *dirStack,
+	helper pathHelper, matches matchesHandler) error {
+
+	err := segsGlob(rest, 0, dir, matchedPath, helper, matches)
+	if err != nil {
+		return err
+	}
+
+	if stack.isCycle(dir, entry, helper) {
+		return nil
+	}
+
+	entries, err := helper.getEntries(dir)
+	if err != nil {
+		return matches.onError(newGlobError(OpReadDir, matchedPath, dir, err))
+	}
+
+	stack.push(dir)
+	defer stack.pop()
+
+	for _, e := range entries {
+		if !mayBeDir(e) {
+			continue
+		}
+
+		name := e.Name()
+		err = dotsWalk(appendDir(dir, name, false), appendPath(matchedPath, name), e, rest, stack, helper, matches)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
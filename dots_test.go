@@ -0,0 +1,152 @@
+package expath
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestCleanDots(t *testing.T) {
+	tests := []struct {
+		path, cleaned string
+	}{
+		{"a/b/c", "a/b/c"},
+		{"a//b/", "a//b/"},
+		{"a/./b/*", "a/b/*"},
+		{"src/*/../shared/**/*.go", "src/shared/**/*.go"},
+		{"a/b/../", "a/"},
+		{"a/..", "."},
+		{"../a/./../b", "../b"},
+		{"/../a", "/a"},
+		{"./a", "a"},
+		{"a/.b/..c", "a/.b/..c"},
+		{"**/a/..", "**"},
+	}
+	if runtime.GOOS != "windows" { // The escaped dots and Separators
+		tests = append(tests, []struct{ path, cleaned string }{
+			{`a/\./b`, `a/\./b`},
+			{`a/\.\./b`, `a/\.\./b`},
+			{`a\/../b`, "b"},
+			{`a\\/../b`, "b"},
+		}...)
+	}
+
+	for _, tt := range tests {
+		if cleaned, err := cleanDots(tt.path, true); err != nil || cleaned != filepath.FromSlash(tt.cleaned) {
+			t.Errorf("cleanDots(%#q) = %#q, %v want %#q", tt.path, cleaned, err, tt.cleaned)
+		}
+	}
+
+	for _, pattern := range []string{"**/../a", "a/**/./..", "**/s/../../*/a.go"} {
+		if _, err := cleanDots(pattern, true); err != errBadPattern {
+			t.Errorf("cleanDots(%#q) error = %v want %v", pattern, err, errBadPattern)
+		}
+		if _, err := Match(pattern, "a"); err != errBadPattern {
+			t.Errorf("Match(%#q) error = %v want %v", pattern, err, errBadPattern)
+		}
+		if _, _, err := Glob(pattern, t.TempDir()); err == nil {
+			t.Errorf("Glob(%#q) error = nil want %v", pattern, errBadPattern)
+		}
+	}
+	if cleaned, _ := cleanDots("**/..", false); cleaned != "." {
+		t.Errorf("cleanDots(`**/..`) of the name = %#q want `.`", cleaned)
+	}
+}
+
+func TestMatchDots(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		matched       bool
+	}{
+		{"a/./b/*", "a/b/c", true},
+		{"a/./b/*", "a/./b/c", true},
+		{"a/b/*", "a/x/../b/c", false}, // The name isn't cleaned
+		{"a/b/*", "a/./b/c", false},
+		{"*", "a/..", false},
+		{"src/*/../shared/**/*.go", "src/shared/a/b.go", true},
+		{"src/*/../shared/**/*.go", "src/x/../shared/b.go", true},
+		{"src/*/../shared/**/*.go", "src/x/shared/b.go", false},
+		{"src/*/../shared/**/*.go", "src/x/y/../shared/b.go", false},
+		{"**/./b", "a/b", true},
+		{"**/./b", "a/./b", true},
+		{"a/b/..", "a", true},
+		{"a/b/..", "a/b/..", true},
+	}
+
+	for _, tt := range tests {
+		if matched, err := Match(tt.pattern, tt.name); matched != tt.matched || err != nil {
+			t.Errorf("Match(%#q, %#q) = %v, %v want %v", tt.pattern, tt.name, matched, err, tt.matched)
+		}
+		if matched := MustCompile(tt.pattern).Match(tt.name); matched != tt.matched {
+			t.Errorf("Compile(%#q).Match(%#q) = %v want %v", tt.pattern, tt.name, matched, tt.matched)
+		}
+		if e, err := Explain(tt.pattern, tt.name); err != nil || e.Matched != tt.matched {
+			t.Errorf("Explain(%#q, %#q) = %v, %v want %v", tt.pattern, tt.name, e, err, tt.matched)
+		}
+	}
+
+	// The captures are of the cleaned pattern, either way the name matches.
+	for _, name := range []string{"src/shared/a/b.go", "src/x/../shared/a/b.go"} {
+		captures, matched, err := MatchCaptures("src/*/../shared/**/*.go", name)
+		if want := []string{"a", "b"}; !matched || err != nil || !reflect.DeepEqual(captures, want) {
+			t.Errorf("MatchCaptures(%#q) = %q, %v, %v want %q", name, captures, matched, err, want)
+		}
+	}
+
+	p := MustCompile("src/*/../shared/**/*.go")
+	for _, dir := range []string{"src", "src/shared", "src/x", "src/x/..", "src/x/../shared/a"} {
+		if !p.CanMatchUnder(dir) {
+			t.Errorf("CanMatchUnder(%#q) = false want true", dir)
+		}
+	}
+	if p.CanMatchUnder("lib") {
+		t.Errorf("CanMatchUnder(`lib`) = true want false")
+	}
+}
+
+func TestGlobDots(t *testing.T) {
+	root := makeTestTree(t, "src/x/a.go", "src/y/b.txt", "src/shared/s/k.go", "src/shared/m.go", "a/b/c", "a/d/b/e")
+
+	tests := []struct {
+		pattern  string
+		physical []string
+		lexical  []string
+	}{
+		{"src/*/../shared/**/*.go",
+			[]string{"src/shared/../shared/m.go", "src/shared/../shared/s/k.go", "src/x/../shared/m.go",
+				"src/x/../shared/s/k.go", "src/y/../shared/m.go", "src/y/../shared/s/k.go"},
+			[]string{"src/shared/m.go", "src/shared/s/k.go"}},
+		{"a/./b/*", []string{"a/./b/c"}, []string{"a/b/c"}},
+		{"**/./b/*", []string{"a/./b/c", "a/d/./b/e"}, []string{"a/b/c", "a/d/b/e"}},
+		{"src/**/x/../shared/*.go", []string{"src/x/../shared/m.go"}, []string{"src/shared/m.go"}},
+		{"**/b/../b/*", []string{"a/b/../b/c", "a/d/b/../b/e"}, []string{"a/b/c", "a/d/b/e"}},
+		{"src/**/s/../m.go", []string{"src/shared/s/../m.go"}, []string{"src/shared/m.go"}},
+	}
+
+	for _, tt := range tests {
+		for _, lexical := range []bool{false, true} {
+			want := tt.physical
+			var opts []GlobOption
+			if lexical {
+				want, opts = tt.lexical, []GlobOption{LexicalDots()}
+			}
+
+			matches, _, err := Glob(tt.pattern, root, opts...)
+			if err != nil {
+				t.Fatalf("Glob(%#q) error: %v", tt.pattern, err)
+			}
+			sort.Strings(matches)
+			if !reflect.DeepEqual(matches, want) {
+				t.Errorf("Glob(%#q) lexical %v = %q want %q", tt.pattern, lexical, matches, want)
+			}
+
+			for _, m := range matches {
+				if matched, _ := Match(tt.pattern, m); !matched {
+					t.Errorf("Glob(%#q) = %#q, but it doesn't Match", tt.pattern, m)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package expath
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// The operations of the GlobErrors.
+//
+const (
+	OpReadDir = "readdir" // reading a directory
+	OpStat    = "stat"    // getting the file information of a literal path
+	OpPattern = "pattern" // parsing the pattern, the Err is filepath.ErrBadPattern
+	OpFilter  = "filter"  // evaluating the predicates of the Filter option
+	OpConfine = "confine" // confining to the root by the Confined option, the Err is ErrEscapesRoot
+	OpExpand  = "expand"  // expanding the pattern by the ExpandPattern option
+)
+
+// GlobError records an error encountered by the glob routine, with the operation, the pattern,
+// the root and the path that caused it. It supports errors.Is and errors.As for the underlying error,
+// such as fs.ErrPermission or filepath.ErrBadPattern.
+//
+type GlobError struct {
+	Op      string // one of the Op constants
+	Pattern string // the pattern to glob
+	Root    string // the root that the pattern based on, as the atRoot returned by Glob
+	Path    string // the OS path that failed, empty for OpPattern, OpExpand (and OpConfine of the rejected pattern)
+	Matched string // the matched path (relative to the Root) where the error encountered, empty for OpPattern
+	Err     error
+}
+
+func newGlobError(op, matched, path string, err error) *GlobError {
+	return &GlobError{Op: op, Path: path, Matched: matched, Err: err}
+}
+
+func (e *GlobError) Error() string {
+	msg := e.Err.Error()
+	if pe, ok := e.Err.(*fs.PathError); ok && pe.Path == e.Path {
+		msg = pe.Op + ": " + pe.Err.Error()
+	}
+
+	if e.Path == "" {
+		return fmt.Sprintf("expath: %s %q: %s", e.Op, e.Pattern, msg)
+	}
+	return fmt.Sprintf("expath: %s %s: %s (pattern %q)", e.Op, e.Path, msg, e.Pattern)
+}
+
+func (e *GlobError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is the list of the GlobErrors collected by the ContinueOnError option,
+// which is returned alongside the partial matches.
+// It supports errors.Is and errors.As for each of the errors, just as errors.Join does.
+//
+type Errors []*GlobError
+
+func (e Errors) Error() string {
+	var b strings.Builder
+	for i, err := range e {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,154 @@
+package expath
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// failingPathHelper fails to get the entries of the dirs (the base names) with the errors.
+//
+type failingPathHelper struct {
+	filePathHelper
+	fails map[string]error
+}
+
+func (h failingPathHelper) getEntries(dir string) ([]fs.DirEntry, error) {
+	if err, ok := h.fails[filepath.Base(dir)]; ok {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: err}
+	}
+	return h.filePathHelper.getEntries(dir)
+}
+
+func globWithHelper(pattern, root string, helper pathHelper, opts ...GlobOption) ([]string, error) {
+	var mh matchedSet
+	err := newGlobOptions(opts).glob(pattern, root, helper, &mh)
+
+	sort.Strings(mh.matches)
+	return mh.matches, err
+}
+
+func TestErrorsPolicy(t *testing.T) {
+	root := makeTestTree(t, "a/x.txt", "locked/y.txt", "broken/z.txt", "c/w.txt")
+
+	helper := failingPathHelper{fails: map[string]error{
+		"locked": fs.ErrPermission,
+		"broken": errors.New("broken"),
+	}}
+
+	matches, err := globWithHelper("**/*.txt", root, helper)
+	if err == nil {
+		t.Errorf("Glob() = %q, %q want the traversal error", matches, errp(err))
+	}
+
+	matches, err = globWithHelper("**/*.txt", root, helper, IgnorePermissionErrors())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Glob(IgnorePermissionErrors()) = %q, %q want the broken error", matches, errp(err))
+	}
+
+	matches, err = globWithHelper("**/*.txt", root, helper, ContinueOnError())
+	if strings.Join(matches, " ") != "a/x.txt c/w.txt" {
+		t.Errorf("Glob(ContinueOnError()) = %q want the partial matches", matches)
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Glob(ContinueOnError()) error = %q want 2 Errors", errp(err))
+	}
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Glob(ContinueOnError()) error = %q want errors.Is fs.ErrPermission", errp(err))
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Matched < errs[j].Matched })
+	if errs[0].Matched != "broken" || errs[0].Path != filepath.Join(root, "broken") ||
+		errs[1].Matched != "locked" || errs[1].Path != filepath.Join(root, "locked") {
+		t.Errorf("Glob(ContinueOnError()) errors = %v", errs)
+	}
+
+	var ge *GlobError
+	if !errors.As(err, &ge) || ge.Op != OpReadDir || ge.Pattern != "**/*.txt" {
+		t.Errorf("Glob(ContinueOnError()) error = %q want errors.As *GlobError", errp(err))
+	}
+
+	matches, err = globWithHelper("**/*.txt", root, helper, ContinueOnError(), IgnorePermissionErrors())
+	if strings.Join(matches, " ") != "a/x.txt c/w.txt" || !errors.As(err, &errs) || len(errs) != 1 || errs[0].Matched != "broken" {
+		t.Errorf("Glob(ContinueOnError(), IgnorePermissionErrors()) = %q, %q", matches, errp(err))
+	}
+}
+
+func TestErrorsPolicyLeadingDirs(t *testing.T) {
+	root := makeTestTree(t, "a/ok/p/x.go", "a/locked/p/y.go", "a/ok/q/z.go", "b/ok/q/w.go")
+
+	helper := failingPathHelper{fails: map[string]error{"a": fs.ErrPermission}}
+
+	// The dirs before the any-dirs' term fail to be read.
+	tests := []struct {
+		pattern string
+		partial string // the matches at "b" with ContinueOnError
+	}{
+		{"*/*/**/*.go", "b/ok/q/w.go"},
+		{"a/*/**", ""},
+		{"*/*/q/**/*.go", "b/ok/q/w.go"},
+	}
+
+	for _, tt := range tests {
+		_, err := globWithHelper(tt.pattern, root, helper)
+		var ge *GlobError
+		if !errors.As(err, &ge) || ge.Op != OpReadDir || ge.Pattern != tt.pattern || filepath.Clean(ge.Root) != root || ge.Matched != "a" {
+			t.Errorf("Glob(%#q) error = %q want the readdir GlobError of `a`", tt.pattern, errp(err))
+		}
+
+		matches, err := globWithHelper(tt.pattern, root, helper, ContinueOnError())
+		var errs Errors
+		if strings.Join(matches, " ") != tt.partial || !errors.As(err, &errs) || len(errs) != 1 || errs[0].Pattern != tt.pattern {
+			t.Errorf("Glob(%#q, ContinueOnError()) = %q, %q want %q and the Errors", tt.pattern, matches, errp(err), tt.partial)
+		}
+
+		matches, err = globWithHelper(tt.pattern, root, helper, IgnorePermissionErrors())
+		if strings.Join(matches, " ") != tt.partial || err != nil {
+			t.Errorf("Glob(%#q, IgnorePermissionErrors()) = %q, %q want %q", tt.pattern, matches, errp(err), tt.partial)
+		}
+
+		var seen []string
+		err = newGlobOptions(nil).glob(tt.pattern, root, helper, &matchesFunc{globFn: func(info GlobInfo, err error) error {
+			if err != nil {
+				seen = append(seen, info.Path())
+			}
+			return nil
+		}})
+		if err != nil || strings.Join(seen, " ") != "a" {
+			t.Errorf("GlobFn(%#q) errors seen = %q, %q want [a]", tt.pattern, seen, errp(err))
+		}
+	}
+}
+
+func TestGlobError(t *testing.T) {
+	root := makeTestTree(t, "a/x.txt")
+
+	_, _, err := Glob("a/[", root)
+
+	var ge *GlobError
+	if !errors.As(err, &ge) || ge.Op != OpPattern || ge.Pattern != "a/[" || !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Glob(`a/[`) = %q want the pattern GlobError", errp(err))
+	}
+
+	_, _, err = Glob("**/*.txt", root, Filter(func(entry fs.DirEntry) (bool, error) {
+		return false, fs.ErrInvalid
+	}))
+	if !errors.As(err, &ge) || ge.Op != OpFilter || ge.Pattern != "**/*.txt" || ge.Matched != "a/x.txt" ||
+		ge.Path != filepath.Join(root, "a", "x.txt") || !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Glob(Filter(...)) = %q want the filter GlobError", errp(err))
+	}
+
+	helper := failingPathHelper{fails: map[string]error{"a": fs.ErrPermission}}
+	_, err = globWithHelper("a/*.txt", root, helper)
+	if !errors.As(err, &ge) || ge.Op != OpReadDir || ge.Path != filepath.Join(root, "a") || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Glob(`a/*.txt`) = %q want the readdir GlobError", errp(err))
+	}
+	if want := "expath: readdir " + filepath.Join(root, "a") + ": open: permission denied (pattern \"a/*.txt\")"; err.Error() != want {
+		t.Errorf("GlobError.Error() = %q want %q", err.Error(), want)
+	}
+}
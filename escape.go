@@ -0,0 +1,64 @@
+package expath
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Escape returns the pattern that matches the name literally, all the meta characters of the name
+// ('*', '?', '[', ']', '{', '}' and '\\') are quoted for the current path style:
+// they are escaped by '\\', except on Windows, where '\\' is the Separator and they are quoted by '[' and ']'.
+// The separators of the name are kept, so the name may be a path, such as a directory to build a pattern:
+//
+//	pattern := expath.Escape(userDir) + "/**/*.csv"
+//
+func Escape(name string) string {
+	if !strings.ContainsAny(name, escapedChars) {
+		return name
+	}
+
+	windows := runtime.GOOS == "windows"
+
+	var b strings.Builder
+	b.Grow(len(name) + 8)
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+
+		switch {
+		case strings.IndexByte(escapedChars, c) < 0:
+			b.WriteByte(c)
+		case !windows:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\\' || c == ']' || c == '}':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('[')
+			b.WriteByte(c)
+			b.WriteByte(']')
+		}
+	}
+
+	return b.String()
+}
+
+const escapedChars = `*?[]{}\`
+
+// unescape removes the escaping backslashes of the pattern (except on Windows).
+//
+func unescape(pattern string) string {
+	if !escaping || strings.IndexByte(pattern, '\\') < 0 {
+		return pattern
+	}
+
+	var b strings.Builder
+	b.Grow(len(pattern))
+
+	for i := 0; i < len(pattern); {
+		c, _, next := patternChar(pattern, i)
+		b.WriteByte(c)
+		i = next
+	}
+	return b.String()
+}
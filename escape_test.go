@@ -0,0 +1,154 @@
+package expath
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type escapeTest struct {
+	name, escaped string
+}
+
+func TestEscape(t *testing.T) {
+	tests := []escapeTest{
+		{"abc", "abc"},
+		{"/home/u/data", "/home/u/data"},
+		{"a*b", `a\*b`},
+		{"x[1]/a?b", `x\[1\]/a\?b`},
+		{"{a,b}", `\{a,b\}`},
+		{`a\b`, `a\\b`},
+		{"", ""},
+	}
+	if runtime.GOOS == "windows" {
+		tests = []escapeTest{
+			{"abc", "abc"},
+			{`c:\home\u`, `c:\home\u`},
+			{"a*b", "a[*]b"},
+			{`x[1]\a?b`, `x[[]1]\a[?]b`},
+			{"{a,b}", "[{]a,b}"},
+		}
+	}
+
+	for _, tt := range tests {
+		if escaped := Escape(tt.name); escaped != tt.escaped {
+			t.Errorf("Escape(%#q) = %#q want %#q", tt.name, escaped, tt.escaped)
+		}
+
+		if tt.name == "" {
+			continue
+		}
+		if matched, err := Match(Escape(tt.name), tt.name); !matched || err != nil {
+			t.Errorf("Match(Escape(%#q), %#q) = %v, %q want true", tt.name, tt.name, matched, errp(err))
+		}
+	}
+}
+
+func TestGlobEscaped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the names with '*' or '?' are invalid on Windows")
+	}
+
+	root := makeTestTree(t, "x[1]/a*b", "x[1]/axb", "x[1]/c/a*b", "x1/a*b")
+
+	tests := []struct {
+		pattern, matches string
+	}{
+		{Escape("x[1]/a*b"), "x[1]/a*b"},
+		{Escape("x[1]") + "/*", "x[1]/a*b x[1]/axb x[1]/c"},
+		{Escape("x[1]") + "/**/" + Escape("a*b"), "x[1]/a*b x[1]/c/a*b"},
+		{"*/" + Escape("a*b"), "x1/a*b x[1]/a*b"},
+		{"x[1]/a*b", "x1/a*b"},
+	}
+
+	for _, tt := range tests {
+		matches := strings.Join(sortedGlob(t, tt.pattern, root), " ")
+		if matches != tt.matches {
+			t.Errorf("Glob(%#q) = %q want %q", tt.pattern, matches, tt.matches)
+		}
+	}
+}
+
+func TestPlainSeparators(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("nothing is escaped on Windows")
+	}
+
+	tests := []struct {
+		pattern, plain string
+		segs           []Segment
+	}{
+		{`a\/**`, `a/**`, []Segment{{"a/", 1}, {"**", -1}}},
+		{`a\\/**`, `a\\/**`, []Segment{{`a\\/`, 1}, {"**", -1}}},
+		{`**\/a\*b`, `**/a\*b`, []Segment{{"**/", -1}, {`a\*b`, 1}}},
+		{`x/\**/y`, `x/\**/y`, []Segment{{`x/\**/y`, 3}}},
+	}
+
+	for _, tt := range tests {
+		if plain := plainSeparators(tt.pattern); plain != tt.plain {
+			t.Errorf("plainSeparators(%#q) = %#q want %#q", tt.pattern, plain, tt.plain)
+		}
+		if segs, _ := Segments(tt.pattern); !reflect.DeepEqual(segs, tt.segs) {
+			t.Errorf("Segments(%#q) = %v want %v", tt.pattern, segs, tt.segs)
+		}
+	}
+}
+
+// TestGlobEscapedConformance checks that Glob(p) ⊆ {x : Match(p, x)} for the escaped patterns, and further that
+// Glob finds all of them, on the names with the meta characters.
+//
+func TestGlobEscapedConformance(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the names with '*' or '?' are invalid on Windows")
+	}
+
+	names := []string{"a*b", "a?b", "[x]", "**", "*", `a\b`, "{a,b}", "axb"}
+
+	var tree []string
+	for _, dir := range names {
+		tree = append(tree, "d/"+dir+"/"+names[len(dir)%len(names)], dir+"/"+dir+"/f", "e/"+dir)
+	}
+	tree = append(tree, "a/b/c")
+	root := makeTestTree(t, tree...)
+
+	comps := []string{"*", "**", "?", "d", "e", "f", `a\/b`, `a\*b`, `a?b`, `\[x\]`, `\*\*`, `\**`, `\*`, `a\\b`, `\{a,b\}`, `[a]*`}
+
+	// All the patterns of up to 3 components.
+	patterns := append([]string(nil), comps...)
+	for n := 1; n < 3; n++ {
+		for _, prefix := range patterns {
+			if strings.Count(prefix, "/") != n-1 {
+				continue
+			}
+			for _, comp := range comps {
+				patterns = append(patterns, prefix+"/"+comp)
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "**") { // The leaves only
+			continue
+		}
+
+		matches, _, err := Glob(pattern, root)
+		if err != nil {
+			t.Fatalf("Glob(%#q) error: %v", pattern, err)
+		}
+
+		for _, m := range matches {
+			if matched, _ := Match(pattern, m); !matched {
+				t.Errorf("Glob(%#q) = %#q, but it doesn't Match", pattern, m)
+			}
+		}
+
+		walked := walkMatched(t, pattern, root)
+		sort.Strings(matches)
+		sort.Strings(walked)
+		if !reflect.DeepEqual(matches, walked) {
+			t.Errorf("Glob(%#q) = %q want %q", pattern, matches, walked)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package expath
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUndefinedVar is the error of expanding an undefined environment variable, use errors.Is to check it.
+//
+var ErrUndefinedVar = errors.New("undefined variable")
+
+// An ExpandOption configures the expansion of Expand and the ExpandPattern option.
+//
+type ExpandOption func(*expander)
+
+type expander struct {
+	lookupEnv  func(name string) (string, bool)
+	lookupHome func(username string) (string, error)
+}
+
+// LookupEnv returns an ExpandOption that looks up the variables by the fn instead of os.LookupEnv.
+//
+func LookupEnv(fn func(name string) (string, bool)) ExpandOption {
+	return func(e *expander) {
+		e.lookupEnv = fn
+	}
+}
+
+// LookupHome returns an ExpandOption that looks up the home directories by the fn, the username is empty for
+// the current user, instead of os.UserHomeDir and os/user.Lookup.
+//
+func LookupHome(fn func(username string) (string, error)) ExpandOption {
+	return func(e *expander) {
+		e.lookupHome = fn
+	}
+}
+
+func newExpander(opts []ExpandOption) *expander {
+	e := &expander{lookupEnv: os.LookupEnv, lookupHome: lookupHome}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func lookupHome(username string) (string, error) {
+	if username == "" {
+		return os.UserHomeDir()
+	}
+
+	u, err := user.Lookup(username)
+	if err != nil {
+		return "", err
+	}
+	return u.HomeDir, nil
+}
+
+// Expand expands the home directories and the environment variables of the pattern:
+//
+//	'~'          the home directory of the current user, at the beginning of the pattern
+//	'~user'      the home directory of the user, at the beginning of the pattern
+//	'$VAR'       the environment variable, the name is of letters, digits and '_'
+//	'${VAR}'     the environment variable
+//
+// The expanded values are escaped (see Escape), so they match literally instead of being wildcards.
+// The escaped characters (by '\\', except on Windows) and the character classes are kept as is,
+// a '$' that isn't followed by a name is kept too.
+// An error wrapping ErrUndefinedVar is returned for an undefined variable.
+//
+// The pattern expanded to an absolute path is still based on the root by Glob, use the ExpandPattern option
+// to glob it from the expanded directory instead.
+//
+func Expand(pattern string, opts ...ExpandOption) (string, error) {
+	base, rest, err := newExpander(opts).expand(pattern)
+	if err != nil {
+		return "", fmt.Errorf("expath: expand %w", err)
+	}
+	if base == "" {
+		return rest, nil
+	}
+
+	if rest == "" {
+		return Escape(base), nil
+	}
+	return Escape(base) + "/" + rest, nil
+}
+
+// ExpandPattern returns a GlobOption that expands the pattern (as Expand) before the glob.
+// If the leading '~' or variable (followed by a Separator) is expanded to an absolute path, the path replaces
+// the root (as a volume name of the pattern does), so `~/projects/**/*.md` is globbed from the home directory.
+// Such a pattern is always rejected by the Confined option, since it leaves the root.
+// The GlobErrors report the pattern before the expansion.
+//
+func ExpandPattern(opts ...ExpandOption) GlobOption {
+	return func(o *globOptions) {
+		o.expander = newExpander(opts)
+	}
+}
+
+// expand expands the pattern. If the leading term is expanded to an absolute path, the path is returned
+// as the (literal) base, and the rest is the pattern following it (without the leading separators).
+//
+func (e *expander) expand(pattern string) (base, rest string, err error) {
+	var b strings.Builder
+	nLen := len(pattern)
+
+	i := 0
+	if nLen > 0 && pattern[0] == '~' {
+		to := 1
+		for to < nLen && !isDirSeparator(pattern, to) {
+			to++
+		}
+
+		home, err := e.lookupHome(pattern[1:to])
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", pattern[:to], err)
+		}
+
+		if base, ok := absBase(home, pattern, to); ok {
+			rest, err = e.expandVars(pattern[to:])
+			return base, strings.TrimLeft(rest, `/\`), err
+		}
+
+		b.WriteString(Escape(home))
+		i = to
+	} else if nLen > 0 && pattern[0] == '$' {
+		if value, to, ok, err := e.expandVar(pattern, 0); err != nil {
+			return "", "", err
+		} else if ok {
+			if base, ok := absBase(value, pattern, to); ok {
+				rest, err = e.expandVars(pattern[to:])
+				return base, strings.TrimLeft(rest, `/\`), err
+			}
+		}
+	}
+
+	rest, err = e.expandVars(pattern[i:])
+	b.WriteString(rest)
+	return "", b.String(), err
+}
+
+// absBase reports whether the value of the leading term (ends at the to) is an absolute path as the base.
+//
+func absBase(value, pattern string, to int) (string, bool) {
+	if !filepath.IsAbs(value) || (to < len(pattern) && !isDirSeparator(pattern, to)) {
+		return "", false
+	}
+	return value, true
+}
+
+// expandVars expands the variables of the pattern.
+//
+func (e *expander) expandVars(pattern string) (string, error) {
+	if strings.IndexByte(pattern, '$') < 0 {
+		return pattern, nil
+	}
+
+	var b strings.Builder
+	nLen := len(pattern)
+
+	for i := 0; i < nLen; {
+		switch c, escaped, next := patternChar(pattern, i); {
+		case escaped:
+			b.WriteString(pattern[i:next])
+			i = next
+
+		case c == '[':
+			to := strings.IndexByte(pattern[i+1:], ']')
+			if to < 0 {
+				to = nLen
+			} else {
+				to += i + 2
+			}
+			b.WriteString(pattern[i:to])
+			i = to
+
+		case c == '$':
+			value, to, ok, err := e.expandVar(pattern, i)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				b.WriteString(Escape(value))
+			} else {
+				b.WriteString(pattern[i:to])
+			}
+			i = to
+
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expandVar expands the variable beginning at the '$' (pattern[i]), and returns where it ends.
+// It isn't ok if the '$' isn't followed by a name.
+//
+func (e *expander) expandVar(pattern string, i int) (value string, to int, ok bool, err error) {
+	nLen := len(pattern)
+	from := i + 1
+
+	var name string
+	if from < nLen && pattern[from] == '{' {
+		end := strings.IndexByte(pattern[from:], '}')
+		if end < 0 {
+			return "", 0, false, fmt.Errorf("%s: missing '}'", pattern[i:])
+		}
+		name, to = pattern[from+1:from+end], from+end+1
+		if !isVarName(name) {
+			return "", 0, false, fmt.Errorf("%s: bad variable name", pattern[i:to])
+		}
+	} else {
+		to = from
+		for to < nLen && isVarChar(pattern[to], to == from) {
+			to++
+		}
+		if to == from {
+			return "", to, false, nil
+		}
+		name = pattern[from:to]
+	}
+
+	value, ok = e.lookupEnv(name)
+	if !ok {
+		return "", 0, false, fmt.Errorf("%s: %w", pattern[i:to], ErrUndefinedVar)
+	}
+	return value, to, true, nil
+}
+
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVarChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVarChar(c byte, first bool) bool {
+	switch {
+	case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9':
+		return !first
+	}
+	return false
+}
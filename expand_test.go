@@ -0,0 +1,135 @@
+package expath
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func testLookupEnv(name string) (string, bool) {
+	value, ok := map[string]string{
+		"HOME":   "/home/me",
+		"SUB":    "src",
+		"STAR":   "a*b",
+		"EMPTY":  "",
+		"CONFIG": "/etc/xdg",
+	}[name]
+	return value, ok
+}
+
+func testLookupHome(username string) (string, error) {
+	switch username {
+	case "":
+		return "/home/me", nil
+	case "bob":
+		return "/home/bob", nil
+	}
+	return "", errors.New("unknown user")
+}
+
+func TestExpand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the tests use the POSIX paths")
+	}
+
+	tests := []struct {
+		pattern, expanded string
+	}{
+		{"**/*.go", "**/*.go"},
+		{"~/projects/**/*.md", "/home/me/projects/**/*.md"},
+		{"~", "/home/me"},
+		{"~bob/*", "/home/bob/*"},
+		{"a/~/b", "a/~/b"},
+		{"$CONFIG/app/*.toml", "/etc/xdg/app/*.toml"},
+		{"${SUB}/**/$STAR", `src/**/a\*b`},
+		{"$SUB-x/${SUB}_y", "src-x/src_y"},
+		{"x$EMPTY/y", "x/y"},
+		{`a\$SUB/$/$1/[$]`, `a\$SUB/$/$1/[$]`},
+	}
+
+	opts := []ExpandOption{LookupEnv(testLookupEnv), LookupHome(testLookupHome)}
+
+	for _, tt := range tests {
+		if expanded, err := Expand(tt.pattern, opts...); expanded != tt.expanded || err != nil {
+			t.Errorf("Expand(%#q) = %#q, %v want %#q", tt.pattern, expanded, err, tt.expanded)
+		}
+	}
+
+	if _, err := Expand("$NOPE/*", opts...); !errors.Is(err, ErrUndefinedVar) {
+		t.Errorf("Expand(`$NOPE/*`) error = %v want %v", err, ErrUndefinedVar)
+	}
+	for _, pattern := range []string{"${SUB", "${S-B}", "~nobody/*"} {
+		if _, err := Expand(pattern, opts...); err == nil {
+			t.Errorf("Expand(%#q) error = nil want an error", pattern)
+		}
+	}
+
+	if matched, _ := Match(must(Expand("**/$STAR", opts...)), "x/a*b"); !matched {
+		t.Errorf("Match(Expand(`**/$STAR`), `x/a*b`) = false want true")
+	}
+	if matched, _ := Match(must(Expand("**/$STAR", opts...)), "x/aXb"); matched {
+		t.Errorf("Match(Expand(`**/$STAR`), `x/aXb`) = true want false")
+	}
+}
+
+func must(s string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestExpandPattern(t *testing.T) {
+	root := makeTestTree(t, "home/p/a.md", "home/p/b/c.md", "src/d.md")
+	home := filepath.Join(root, "home")
+
+	opts := []ExpandOption{
+		LookupEnv(func(name string) (string, bool) { return "src", name == "SUB" }),
+		LookupHome(func(string) (string, error) { return home, nil }),
+	}
+
+	matches, atRoot, err := Glob("~/p/**/*.md", "", ExpandPattern(opts...))
+	sort.Strings(matches)
+	if want := []string{"p/a.md", "p/b/c.md"}; err != nil || !reflect.DeepEqual(matches, want) {
+		t.Errorf("Glob(`~/p/**/*.md`) = %q, %v want %q", matches, err, want)
+	}
+	if filepath.Clean(atRoot) != home {
+		t.Errorf("Glob(`~/p/**/*.md`) atRoot = %q want %q", atRoot, home)
+	}
+
+	if matches, _, err = Glob("$SUB/*.md", root, ExpandPattern(opts...)); err != nil || len(matches) != 1 || matches[0] != "src/d.md" {
+		t.Errorf("Glob(`$SUB/*.md`) = %q, %v want [src/d.md]", matches, err)
+	}
+
+	var ge *GlobError
+	_, _, err = Glob("$NOPE/*.md", root, ExpandPattern(opts...))
+	if !errors.As(err, &ge) || ge.Op != OpExpand || !errors.Is(err, ErrUndefinedVar) {
+		t.Errorf("Glob(`$NOPE/*.md`) error = %v want %v", err, ErrUndefinedVar)
+	}
+
+	if _, _, err = Glob("~/p/*.md", root, ExpandPattern(opts...), Confined()); !errors.Is(err, ErrEscapesRoot) {
+		t.Errorf("Glob(`~/p/*.md`, Confined) error = %v want %v", err, ErrEscapesRoot)
+	}
+
+	// The GlobErrors report the pattern before the expansion.
+	for _, pattern := range []string{"$SUB/[a", "~/p/[a", "$SUB/**/../*.md"} {
+		for _, extra := range [][]GlobOption{nil, {LexicalDots()}, {Confined()}} {
+			_, _, err = Glob(pattern, root, append(extra, ExpandPattern(opts...))...)
+			if !errors.As(err, &ge) || ge.Pattern != pattern {
+				t.Errorf("Glob(%#q) error = %v want the GlobError of the pattern", pattern, err)
+			}
+		}
+	}
+
+	if _, _, err = Glob("$SUB/../../*.md", root, ExpandPattern(opts...), Confined()); !errors.As(err, &ge) || ge.Pattern != "$SUB/../../*.md" {
+		t.Errorf("Glob(`$SUB/../../*.md`, Confined) error = %v want the GlobError of the pattern", err)
+	}
+	bad := Filter(func(fs.DirEntry) (bool, error) { return false, errors.New("bad") })
+	if _, _, err = Glob("$SUB/*.md", root, ExpandPattern(opts...), bad); !errors.As(err, &ge) || ge.Pattern != "$SUB/*.md" {
+		t.Errorf("Glob(`$SUB/*.md`) error = %v want the GlobError of the pattern", err)
+	}
+}
@@ -14,23 +14,20 @@ import (
 //	term:
 //		'**'         matches zero or more directories in a path
 //
+// The '.' and '..' dirs of the pattern are cleaned lexically before matching, and the name that matches
+// the pattern as written (the dot dirs matching themselves) matches too. The name is never cleaned.
+// A '..' following '**' is malformed.
+//
 // As the standard Match function, the only possible returned error is filepath.ErrBadPattern, when pattern
 // is malformed.
 //
 func Match(pattern, name string) (matched bool, err error) {
-	segs, err := scanSegments(pattern)
+	segs, literal, err := dotsSegments(pattern)
 	if err != nil {
 		return false, err
 	}
 
-	switch len(segs) {
-	case 1:
-		return matchASeg(segs[0], name)
-	case 0:
-		return matchASeg(patternSeg{"", 0}, name)
-	default:
-		return matchSegs(segs, name)
-	}
+	return matchDots(segs, literal, name)
 }
 
 // Glob returns the names of all files matching pattern or nil
@@ -42,11 +39,17 @@ func Match(pattern, name string) (matched bool, err error) {
 // Unlike the standard library path/filepath's Glob function, this Glob function has an extra root argument.
 // The root argument indicates that the pattern path based on the root (empty root means the current direction).
 //
-func Glob(pattern, root string) (matches []string, atRoot string, err error) {
+// The opts configure the glob routine, such as Filter, ContinueOnError, Output or Cache.
+// With ContinueOnError, the partial matches are returned alongside the collected Errors.
+//
+func Glob(pattern, root string, opts ...GlobOption) (matches []string, atRoot string, err error) {
 	var helper filePathHelper
 	var mh matchedSet
 
-	err = doGlob(pattern, root, &helper, &mh)
+	o := newGlobOptions(opts)
+	mh.output = o.output
+
+	err = o.glob(pattern, root, &helper, &mh)
 
 	atRoot = mh.root
 	matches = mh.matches
@@ -55,6 +58,9 @@ func Glob(pattern, root string) (matches []string, atRoot string, err error) {
 
 // GlobInfo used for the GlobFunc callback function to supply the glob information.
 //
+// FileInfo retrieves the file information without following the symbolic link,
+// the result is cached, and shared with the predicates of the Filter option.
+//
 type GlobInfo interface {
 	AtRoot() string
 	Path() string
@@ -69,10 +75,42 @@ type GlobInfo interface {
 type GlobFunc func(info GlobInfo, err error) error
 
 // GlobFn uses the GlobFunc callback function to handle each matched file name or encountered file error.
+// The opts are the same as Glob's.
 //
-func GlobFn(pattern, root string, globFn GlobFunc) error {
+func GlobFn(pattern, root string, globFn GlobFunc, opts ...GlobOption) error {
 	var helper filePathHelper
 	var mf matchesFunc
+	mf.globFn = globFn
+
+	return newGlobOptions(opts).glob(pattern, root, &helper, &mf)
+}
+
+// Segment is a segment of the whole pattern, as separated by the any-dirs' term ('**').
+// Dirs indicates how many dirs that the segment has (separated by Separator), -1 for the any-dirs' segment.
+//
+type Segment struct {
+	Pattern string
+	Dirs    int
+}
+
+// AnyDirs reports whether the segment is just the any-dirs' pattern.
+//
+func (s Segment) AnyDirs() bool {
+	return s.Dirs < 0
+}
+
+// Segments separates the pattern into segments by the any-dirs' term ('**'),
+// the same as Match and Glob do.
+//
+func Segments(pattern string) ([]Segment, error) {
+	segs, err := scanSegments(pattern)
+	if err != nil {
+		return nil, err
+	}
 
-	return doGlob(pattern, root, &helper, &mf)
+	segments := make([]Segment, len(segs))
+	for i, seg := range segs {
+		segments[i] = Segment{seg.pattern, seg.dirs}
+	}
+	return segments, nil
 }
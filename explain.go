@@ -0,0 +1,196 @@
+package expath
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Explanation is the trace of matching a name against a pattern, returned by Explain.
+//
+type Explanation struct {
+	Pattern  string    `json:"pattern"`
+	Name     string    `json:"name"`
+	Segments []Segment `json:"segments"`
+	Steps    []Step    `json:"steps"` // the tries of the segments in order
+	Matched  bool      `json:"matched"`
+
+	// The deepest failure of the unmatched name: the segment, the position in the name, and why.
+	FailedSegment int    `json:"failedSegment"`
+	FailedAt      int    `json:"failedAt"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// Step is a try of a segment on a part of the name (Name[From:To]), in the trace of Explain.
+// For the segment following an any-dirs' segment, AnyDirs is the dirs taken by the any-dirs' term,
+// so the tries of a segment show the alternative splits of the '**'.
+// A Step with the Reason isn't a try, but why the segment can't be tried at the position.
+//
+type Step struct {
+	Segment int    `json:"segment"`
+	Pattern string `json:"pattern"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	AnyDirs string `json:"anyDirs,omitempty"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Explain matches the name against the pattern the same as Match does, and returns the trace of it,
+// filepath.ErrBadPattern is returned if the pattern is malformed.
+// The Pattern of the explanation is cleaned of the '.' and '..' dirs, as Match does, unless the name
+// matches only the pattern as written, then the trace is of the pattern as written.
+//
+func Explain(pattern, name string) (*Explanation, error) {
+	if err := validatePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	cleaned, _ := cleanDots(pattern, true)
+	e, err := explain(cleaned, name)
+	if err != nil || e.Matched || !hasDotDirs(pattern, true) {
+		return e, err
+	}
+
+	if literal, err := explain(plainSeparators(pattern), name); err != nil || literal.Matched {
+		return literal, err
+	}
+	return e, nil
+}
+
+func explain(pattern, name string) (*Explanation, error) {
+	segs, err := scanSegments(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Explanation{Pattern: pattern, Name: name, Segments: make([]Segment, len(segs)), FailedSegment: -1}
+	for i, seg := range segs {
+		e.Segments[i] = Segment{seg.pattern, seg.dirs}
+	}
+
+	switch len(segs) {
+	case 0:
+		if e.Matched = (name == ""); !e.Matched {
+			e.Reason = "the empty pattern matches only the empty name"
+		}
+		return e, nil
+
+	case 1:
+		e.Matched, err = matchASeg(segs[0], name)
+		e.Steps = []Step{{Segment: 0, Pattern: segs[0].pattern, To: len(name), Matched: e.Matched}}
+
+	default:
+		m := segsMatcher{segs: segs, name: name, tracing: true}
+		e.Matched, err = m.match()
+		e.Steps = m.trace
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.Matched {
+		e.explainFailure()
+	}
+	return e, nil
+}
+
+// explainFailure finds the deepest failure in the steps, where the failed try of a normal segment
+// is refined to the first dir of the name that isn't matched by the dir of the segment.
+//
+func (e *Explanation) explainFailure() {
+	for _, step := range e.Steps {
+		if step.Matched {
+			continue
+		}
+
+		at, reason := step.From, step.Reason
+		if reason == "" {
+			if e.Segments[step.Segment].AnyDirs() {
+				reason = fmt.Sprintf("%q doesn't match %q", step.Pattern, e.Name[step.From:step.To])
+			} else {
+				at, reason = explainDirs(step.Pattern, e.Name, step.From, step.To)
+			}
+		}
+
+		if e.FailedSegment < 0 || at > e.FailedAt {
+			e.FailedSegment, e.FailedAt, e.Reason = step.Segment, at, reason
+		}
+	}
+}
+
+// explainDirs finds the first dir of the name[from:to] that isn't matched by the corresponding dir of the pattern.
+//
+func explainDirs(pattern, name string, from, to int) (int, string) {
+	pLen := len(pattern)
+
+	for i, j := 0, from; ; {
+		pTo, pMark := scanDirs(pattern, i, pLen, 1)
+		nTo, nMark := scanDirs(name, j, to, 1)
+
+		switch {
+		case pMark < 0 && nMark < 0:
+			return from, fmt.Sprintf("%q doesn't match %q", pattern, name[from:to])
+		case pMark < 0:
+			return j, fmt.Sprintf("%q has no more dirs for %q", pattern, name[j:to])
+		case nMark < 0:
+			return j, fmt.Sprintf("no more dirs in the name for %q", pattern[i:])
+		}
+
+		pDir, nDir := trimPath(pattern[i:pTo]), trimPath(name[j:nTo])
+		if matched, _ := filepath.Match(pDir, nDir); !matched {
+			return j, fmt.Sprintf("%q doesn't match %q", pDir, nDir)
+		}
+
+		i, j = pTo, nTo
+	}
+}
+
+// String renders the explanation in the human-readable form, for the command line and logs.
+//
+func (e *Explanation) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "pattern %q, name %q: ", e.Pattern, e.Name)
+	if e.Matched {
+		b.WriteString("matched\n")
+	} else {
+		b.WriteString("not matched\n")
+	}
+
+	for i, seg := range e.Segments {
+		if seg.AnyDirs() {
+			fmt.Fprintf(&b, "\tsegment %d: %-16q any dirs\n", i, seg.Pattern)
+		} else {
+			fmt.Fprintf(&b, "\tsegment %d: %-16q %d dir(s)\n", i, seg.Pattern, seg.Dirs)
+		}
+	}
+
+	for _, step := range e.Steps {
+		if step.Reason != "" {
+			fmt.Fprintf(&b, "\tsegment %d at %d: %s\n", step.Segment, step.From, step.Reason)
+			continue
+		}
+
+		fmt.Fprintf(&b, "\ttry segment %d %q on %q", step.Segment, step.Pattern, e.Name[step.From:step.To])
+		if step.Segment > 0 && e.Segments[step.Segment-1].AnyDirs() {
+			fmt.Fprintf(&b, " (** took %q)", step.AnyDirs)
+		}
+		if step.Matched {
+			b.WriteString(": matched\n")
+		} else {
+			b.WriteString(": not matched\n")
+		}
+	}
+
+	if !e.Matched {
+		if e.FailedSegment >= 0 {
+			fmt.Fprintf(&b, "\tfailed at segment %d, offset %d: %s\n", e.FailedSegment, e.FailedAt, e.Reason)
+		} else {
+			fmt.Fprintf(&b, "\tfailed: %s\n", e.Reason)
+		}
+	}
+
+	return b.String()
+}
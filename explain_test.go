@@ -0,0 +1,55 @@
+package expath
+
+import (
+	"strings"
+	"testing"
+)
+
+type explainTest struct {
+	pattern, name string
+	matched       bool
+	failedSegment int
+	failedAt      int
+	reason        string
+}
+
+var explainTests = []explainTest{
+	{"src/**/test/*.go", "src/a/test/b.go", true, -1, 0, ""},
+	{"src/**/test/*.go", "src/pkg/tests/a.go", false, 2, 8, `"test" doesn't match "tests"`},
+	{"src/**/test/*.go", "lib/test/a.go", false, 0, 0, `"src" doesn't match "lib"`},
+	{"a/b/*.go", "a/c/d.go", false, 0, 2, `"b" doesn't match "c"`},
+	{"a/b/*.go", "a/b", false, 0, 3, `no more dirs in the name for "*.go"`},
+	{"**/a/**/b", "a/c/a/d", false, 3, 6, `"b" doesn't match "d"`},
+	{"/**/x", "a/x", false, 0, 0, "the rooted pattern requires the rooted name"},
+	{"", "a", false, -1, 0, "the empty pattern matches only the empty name"},
+}
+
+func TestExplain(t *testing.T) {
+	for _, tt := range explainTests {
+		e, err := Explain(tt.pattern, tt.name)
+		if err != nil {
+			t.Errorf("Explain(%#q, %#q) error: %v", tt.pattern, tt.name, err)
+			continue
+		}
+
+		if matched, _ := Match(tt.pattern, tt.name); e.Matched != matched {
+			t.Errorf("Explain(%#q, %#q).Matched = %v, but Match = %v", tt.pattern, tt.name, e.Matched, matched)
+		}
+
+		if e.Matched != tt.matched || e.FailedSegment != tt.failedSegment || e.FailedAt != tt.failedAt || e.Reason != tt.reason {
+			t.Errorf("Explain(%#q, %#q) = %v, %d, %d, %q want %v, %d, %d, %q", tt.pattern, tt.name,
+				e.Matched, e.FailedSegment, e.FailedAt, e.Reason, tt.matched, tt.failedSegment, tt.failedAt, tt.reason)
+		}
+	}
+
+	e, _ := Explain("src/**/test/*.go", "src/pkg/tests/a.go")
+	for _, split := range []string{`(** took "")`, `(** took "pkg/")`} {
+		if !strings.Contains(e.String(), split) {
+			t.Errorf("Explain().String() = %q, want the split %s", e.String(), split)
+		}
+	}
+
+	if _, err := Explain("a/[b", "a/b"); err != errBadPattern {
+		t.Errorf("Explain(`a/[b`) error = %v want %v", err, errBadPattern)
+	}
+}
@@ -0,0 +1,273 @@
+package expath
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+// filterFS is the view of a file system that contains only the files matching any of the patterns.
+//
+type filterFS struct {
+	fsys     fs.FS
+	patterns []*Pattern
+
+	mu    sync.Mutex
+	leads map[string]bool // whether the dir leads to a matching file, memoized
+}
+
+// FilterFS returns a view of the fsys that contains only the files (and dirs) matching any of the patterns,
+// filepath.ErrBadPattern is returned if any of them is malformed.
+// The patterns are matched against the slash-separated names of the fsys (without the leading "./" or '/'),
+// the syntax is the same as in Match, so the alternatives are given as separated patterns,
+// e.g. FilterFS(fsys, "assets/**/*.png", "assets/**/*.svg").
+//
+// The non-matching files are invisible, the non-matching dirs are visible only if they lead to a matching file,
+// so the dirs whose subtree cannot match are pruned from ReadDir and fs.WalkDir. The dirs matching a pattern themselves
+// (e.g. "assets/empty" by "assets/**") are kept as the matching files are, even if they are empty.
+// Whether a dir leads to a matching file is found once and memoized by the view, so the later changes of the fsys
+// that make a dir (in)visible are not seen by the view, a new view sees them.
+// The returned fs.FS also implements the fs.ReadDirFS, fs.StatFS and fs.GlobFS interfaces.
+//
+func FilterFS(fsys fs.FS, patterns ...string) (fs.FS, error) {
+	f := &filterFS{fsys: fsys, leads: make(map[string]bool)}
+
+	for _, pattern := range patterns {
+		p, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.patterns = append(f.patterns, p)
+	}
+
+	return f, nil
+}
+
+func (f *filterFS) matches(name string) bool {
+	for _, p := range f.patterns {
+		if p.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *filterFS) canMatchUnder(dir string) bool {
+	for _, p := range f.patterns {
+		if p.CanMatchUnder(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// leadsToMatch reports whether there is any matching file under the dir.
+// The result of each dir (including the sub dirs searched) is memoized, so each dir is searched at most once.
+//
+func (f *filterFS) leadsToMatch(dir string) (bool, error) {
+	f.mu.Lock()
+	leads, ok := f.leads[dir]
+	f.mu.Unlock()
+	if ok {
+		return leads, nil
+	}
+
+	entries, err := fs.ReadDir(f.fsys, dir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if f.matches(name) {
+			leads = true
+			break
+		}
+
+		if entry.IsDir() && f.canMatchUnder(name) {
+			if ok, err := f.leadsToMatch(name); ok && err == nil { // Skip the unreadable dirs
+				leads = true
+				break
+			}
+		}
+	}
+
+	f.mu.Lock()
+	f.leads[dir] = leads
+	f.mu.Unlock()
+
+	return leads, nil
+}
+
+// isVisible reports whether the named file (or dir) is visible in the view, the entry of it may be nil if it is unknown.
+//
+func (f *filterFS) isVisible(name string, entry fs.DirEntry) (bool, error) {
+	if name == "." || f.matches(name) {
+		return true, nil
+	}
+
+	if entry == nil {
+		info, err := fs.Stat(f.fsys, name)
+		if err != nil {
+			return false, err
+		}
+		entry = fs.FileInfoToDirEntry(info)
+	}
+
+	if !entry.IsDir() || !f.canMatchUnder(name) {
+		return false, nil
+	}
+	return f.leadsToMatch(name)
+}
+
+// check returns the *fs.PathError for the invalid or invisible named file.
+//
+func (f *filterFS) check(op, name string) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	visible, err := f.isVisible(name, nil)
+	if err != nil {
+		var pe *fs.PathError
+		if errors.As(err, &pe) {
+			return &fs.PathError{Op: op, Path: name, Err: pe.Err}
+		}
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if !visible {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// filterEntries returns the visible entries of the dir.
+//
+func (f *filterFS) filterEntries(dir string, entries []fs.DirEntry) ([]fs.DirEntry, error) {
+	visibles := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		visible, err := f.isVisible(path.Join(dir, entry.Name()), entry)
+		if err != nil {
+			return visibles, err
+		}
+		if visible {
+			visibles = append(visibles, entry)
+		}
+	}
+	return visibles, nil
+}
+
+func (f *filterFS) Open(name string) (fs.File, error) {
+	if err := f.check("open", name); err != nil {
+		return nil, err
+	}
+
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		return &filterDir{File: file, fsys: f, name: name}, nil
+	}
+	return file, nil
+}
+
+func (f *filterFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.check("stat", name); err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.fsys, name)
+}
+
+func (f *filterFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.check("readdir", name); err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return f.filterEntries(name, entries)
+}
+
+// Glob returns the names of the visible files matching the pattern, the syntax is the same as in path.Match.
+//
+func (f *filterFS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	names, err := fs.Glob(f.fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]string, 0, len(names))
+	for _, name := range names {
+		visible, err := f.isVisible(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			matches = append(matches, name)
+		}
+	}
+	return matches, nil
+}
+
+// filterDir is the opened dir of the filterFS, that reads only the visible entries.
+//
+type filterDir struct {
+	fs.File
+	fsys *filterFS
+	name string
+
+	entries []fs.DirEntry // The visible entries not read yet
+	read    bool
+}
+
+func (d *filterDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		rd, ok := d.File.(fs.ReadDirFile)
+		if !ok {
+			return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: errors.New("not implemented")}
+		}
+
+		entries, err := rd.ReadDir(-1)
+		if err != nil {
+			return nil, err
+		}
+
+		d.entries, err = d.fsys.filterEntries(d.name, entries)
+		if err != nil {
+			return nil, err
+		}
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
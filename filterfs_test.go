@@ -0,0 +1,160 @@
+package expath
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFilterFS(t *testing.T) {
+	mapfs := fstest.MapFS{
+		"assets/logo.png":       {Data: []byte("png")},
+		"assets/icons/a.svg":    {Data: []byte("svg")},
+		"assets/icons/b.txt":    {Data: []byte("txt")},
+		"assets/fonts/x.ttf":    {Data: []byte("ttf")},
+		"assets/empty":          {Mode: fs.ModeDir},
+		"src/main.go":           {Data: []byte("go")},
+		"src/assets/nested.png": {Data: []byte("png")},
+	}
+
+	fsys, err := FilterFS(mapfs, "assets/**/*.png", "assets/**/*.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = fstest.TestFS(fsys, "assets/logo.png", "assets/icons/a.svg"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"assets/icons/b.txt", "assets/fonts", "assets/empty", "src", "src/assets/nested.png"} {
+		if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(%#q) = %v want fs.ErrNotExist", name, err)
+		}
+	}
+
+	tests := []struct {
+		dir   string
+		names []string
+	}{
+		{".", []string{"assets"}},
+		{"assets", []string{"icons", "logo.png"}},
+		{"assets/icons", []string{"a.svg"}},
+	}
+
+	for _, tt := range tests {
+		entries, err := fs.ReadDir(fsys, tt.dir)
+		if err != nil {
+			t.Fatalf("ReadDir(%#q) error: %v", tt.dir, err)
+		}
+
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("ReadDir(%#q) = %q want %q", tt.dir, names, tt.names)
+		}
+	}
+
+	matches, err := fs.Glob(fsys, "assets/*/*")
+	if err != nil || !reflect.DeepEqual(matches, []string{"assets/icons/a.svg"}) {
+		t.Errorf("Glob(`assets/*/*`) = %q, %v want [assets/icons/a.svg]", matches, err)
+	}
+
+	if _, err = FilterFS(mapfs, "assets/[a"); err != errBadPattern {
+		t.Errorf("FilterFS(`assets/[a`) error = %v want %v", err, errBadPattern)
+	}
+}
+
+// countingFS counts the dirs read of the fs.FS, and keeps the entries returned last.
+//
+type countingFS struct {
+	fstest.MapFS
+	reads   map[string]int
+	entries map[string][]fs.DirEntry
+}
+
+func (c *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := c.MapFS.ReadDir(name)
+	c.reads[name]++
+	c.entries[name] = entries
+	return entries, err
+}
+
+func TestFilterFSReadDirs(t *testing.T) {
+	mapfs := fstest.MapFS{"a/b/c/d/e.txt": {}, "a/b/c/d/f.png": {}, "a/b/g.png": {}}
+	cfs := &countingFS{mapfs, map[string]int{}, map[string][]fs.DirEntry{}}
+
+	fsys, err := FilterFS(cfs, "**/*.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, name)
+		}
+		return err
+	})
+	if want := []string{"a/b/c/d/f.png", "a/b/g.png"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("WalkDir() = %q, %v want %q", names, err, want)
+	}
+
+	// Each dir is read once by the walk, and at most once more to find whether it leads to a matching file.
+	for dir, n := range cfs.reads {
+		if n > 2 {
+			t.Errorf("%#q read %d times want at most 2", dir, n)
+		}
+	}
+
+	// The entries returned by the wrapped fs.FS are not overwritten.
+	if _, err := fs.ReadDir(fsys, "a/b/c/d"); err != nil {
+		t.Fatal(err)
+	}
+	if entries := cfs.entries["a/b/c/d"]; entries[0].Name() != "e.txt" {
+		t.Errorf("ReadDir() overwrote the entries of the wrapped fs.FS: %v", entries)
+	}
+}
+
+func TestFilterFSDirs(t *testing.T) {
+	mapfs := fstest.MapFS{
+		"assets/logo.png":    {},
+		"assets/fonts/x.ttf": {},
+		"assets/empty":       {Mode: fs.ModeDir},
+		"assets/deep/a/b":    {Mode: fs.ModeDir},
+		"src/main.go":        {},
+	}
+
+	tests := []struct {
+		patterns []string
+		names    []string
+	}{
+		// The dirs with no matching descendants are pruned.
+		{[]string{"**/*.png"}, []string{".", "assets", "assets/logo.png"}},
+		{[]string{"assets/*/*.ttf"}, []string{".", "assets", "assets/fonts", "assets/fonts/x.ttf"}},
+		{[]string{"src/**/*.png"}, []string{"."}},
+		// The dirs matching a pattern themselves are kept, even if they are empty.
+		{[]string{"assets/*"}, []string{".", "assets", "assets/deep", "assets/empty", "assets/fonts", "assets/logo.png"}},
+		{[]string{"assets/**/b"}, []string{".", "assets", "assets/deep", "assets/deep/a", "assets/deep/a/b"}},
+		{[]string{"**/empty", "src/*.go"}, []string{".", "assets", "assets/empty", "src", "src/main.go"}},
+	}
+
+	for _, tt := range tests {
+		fsys, err := FilterFS(mapfs, tt.patterns...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			names = append(names, name)
+			return err
+		})
+		if err != nil || !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("WalkDir(%q) = %q, %v want %q", tt.patterns, names, err, tt.names)
+		}
+	}
+}
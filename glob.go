@@ -1,16 +1,17 @@
 package expath
 
 import (
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
-	"runtime"
 )
 
 // doGlob is the main entrance of the glob routine.
 //
 func doGlob(pattern, root string, helper pathHelper, matches matchesHandler) (err error) {
 
+	origin := pattern
 	pattern, root = normalizePath(pattern, root)
 
 	err = matches.setRoot(root)
@@ -18,6 +19,10 @@ func doGlob(pattern, root string, helper pathHelper, matches matchesHandler) (er
 		return
 	}
 
+	if err = validatePattern(pattern); err != nil {
+		return &GlobError{Op: OpPattern, Pattern: origin, Root: root, Err: err}
+	}
+
 	var segs []patternSeg
 
 	segs, err = scanSegments(pattern)
@@ -45,7 +50,7 @@ func segsGlob(segs []patternSeg, curr int,
 	if (segsLen - curr) > 1 {
 		if segs[curr].dirs >= 0 {
 
-			var mh matchedSet
+			mh := matchedDirs{handler: matches}
 			err = normalGlob(dir, matchedPath, trimPath(segs[curr].pattern), helper, &mh)
 			if err != nil {
 				return
@@ -53,9 +58,8 @@ func segsGlob(segs []patternSeg, curr int,
 
 			if (segsLen - curr) > 2 {
 				for _, mp := range mh.matches {
-					err = exglob(segs, curr+2,
+					err = exglobSeg(segs, curr+2,
 						appendDirPath(dir, matchedPath, mp), mp,
-						len(mp), 0,
 						helper, matches)
 					if err != nil {
 						return
@@ -63,7 +67,7 @@ func segsGlob(segs []patternSeg, curr int,
 				}
 			} else {
 				for _, mp := range mh.matches {
-					err = anyDirsGlob(appendDirPath(dir, matchedPath, mp), mp, helper, matches)
+					err = anyDirsGlob(appendDirPath(dir, matchedPath, mp), mp, nil, new(dirStack), helper, matches)
 					if err != nil {
 						return
 					}
@@ -71,9 +75,8 @@ func segsGlob(segs []patternSeg, curr int,
 			}
 
 		} else {
-			err = exglob(segs, curr+1,
+			err = exglobSeg(segs, curr+1,
 				dir, matchedPath,
-				len(matchedPath), 0,
 				helper, matches)
 		}
 
@@ -81,7 +84,7 @@ func segsGlob(segs []patternSeg, curr int,
 		if segs[curr].dirs >= 0 {
 			err = normalGlob(dir, matchedPath, segs[curr].pattern, helper, matches)
 		} else {
-			err = anyDirsGlob(dir, matchedPath, helper, matches)
+			err = anyDirsGlob(dir, matchedPath, nil, new(dirStack), helper, matches)
 		}
 	}
 
@@ -107,27 +110,32 @@ func appendDirPath(dir, appendedPrefix, path string) string {
 }
 
 // exglob does the glob match of the normal pattern segment that following the any-dirs' pattern segment.
+// The stack has the dirs being descended by the segment (excluding the dir), to skip the cycles.
 //
 func exglob(segs []patternSeg, curr int,
 	dir, matchedPath string,
-	mark, pendingDirs int,
+	mark, pendingDirs int, stack *dirStack,
 	helper pathHelper, matches matchesHandler) error {
 
-	names, err := helper.getNames(dir)
+	entries, err := helper.getEntries(dir)
 	if err != nil {
-		return matches.onError(matchedPath, err)
+		return matches.onError(newGlobError(OpReadDir, matchedPath, dir, err))
 	}
-	if len(names) == 0 {
+	if len(entries) == 0 {
 		return nil
 	}
 
+	stack.push(dir)
+	defer stack.pop()
+
 	pendingDirs++
 
 	if pendingDirs >= segs[curr].dirs {
 
 		segsLen := len(segs)
 
-		for _, name := range names {
+		for _, entry := range entries {
+			name := entry.Name()
 			d, p := appendDir(dir, name, false), appendPath(matchedPath, name)
 
 			matched, err := path.Match(trimPath(segs[curr].pattern), trimPath(p[mark:]))
@@ -135,15 +143,19 @@ func exglob(segs []patternSeg, curr int,
 				return err
 			}
 
-			if matched {
-				if curr == segsLen-1 {
-					err = matches.onMatched(p)
-				} else {
-					err = segsGlob(segs, curr+1, d, p, helper, matches)
-				}
+			// Each of the path is visited once by the segment. For the last segment, the matched dir is still
+			// visited for the deeper matches. Otherwise, the rest segments (beginning with the any-dirs' term)
+			// take over the matched dir, which covers all the deeper matches of the segment.
+			if matched && curr < segsLen-1 {
+				err = segsGlob(segs, curr+1, d, p, helper, matches)
 			} else {
-				m, _ := scanDirs(p, mark, len(p), 1)
-				err = exglob(segs, curr, d, p, m, pendingDirs-1, helper, matches)
+				if matched {
+					err = matches.onMatched(p, entry)
+				}
+				if err == nil && mayBeDir(entry) && !stack.isCycle(d, entry, helper) {
+					m, _ := scanDirs(p, mark, len(p), 1)
+					err = exglob(segs, curr, d, p, m, pendingDirs-1, stack, helper, matches)
+				}
 			}
 
 			if err != nil {
@@ -152,9 +164,18 @@ func exglob(segs []patternSeg, curr int,
 		}
 
 	} else {
-		for _, name := range names {
+		for _, entry := range entries {
+			if !mayBeDir(entry) {
+				continue
+			}
+
+			name := entry.Name()
 			d, p := appendDir(dir, name, false), appendPath(matchedPath, name)
-			err = exglob(segs, curr, d, p, mark, pendingDirs, helper, matches)
+			if stack.isCycle(d, entry, helper) {
+				continue
+			}
+
+			err = exglob(segs, curr, d, p, mark, pendingDirs, stack, helper, matches)
 			if err != nil {
 				return err
 			}
@@ -192,32 +213,35 @@ func normalGlob(dir, matchedPath, pattern string,
 	morePattern := (len(tail) > 0)
 
 	if !hasMeta {
+		head = unescape(head)
+
 		dir = appendDir(dir, head, slashes > 0)
 		matchedPath = appendPath(matchedPath, head)
 
-		exists, err := helper.isExist(dir)
+		entry, err := helper.getEntry(dir)
 		if err != nil {
-			return matches.onError(matchedPath, err)
+			return matches.onError(newGlobError(OpStat, matchedPath, dir, err))
 		}
-		if !exists {
+		if entry == nil {
 			return err
 		}
 
 		if morePattern {
 			return normalGlob(dir, matchedPath, tail, helper, matches)
 		}
-		err = matches.onMatched(matchedPath)
+		err = matches.onMatched(matchedPath, entry)
 		if err != nil {
 			return err
 		}
 
 	} else {
-		names, err := helper.getNames(dir)
+		entries, err := helper.getEntries(dir)
 		if err != nil {
-			return matches.onError(matchedPath, err)
+			return matches.onError(newGlobError(OpReadDir, matchedPath, dir, err))
 		}
 
-		for _, name := range names {
+		for _, entry := range entries {
+			name := entry.Name()
 			matched, err := path.Match(head, name)
 			if err != nil {
 				return err
@@ -228,6 +252,10 @@ func normalGlob(dir, matchedPath, pattern string,
 			}
 
 			if morePattern {
+				if !mayBeDir(entry) {
+					continue
+				}
+
 				d, p := appendDir(dir, name, false), appendPath(matchedPath, name)
 				err = normalGlob(d, p, tail, helper, matches)
 				if err != nil {
@@ -235,7 +263,7 @@ func normalGlob(dir, matchedPath, pattern string,
 				}
 			} else {
 				mp := appendPath(matchedPath, name)
-				err = matches.onMatched(mp)
+				err = matches.onMatched(mp, entry)
 				if err != nil {
 					return err
 				}
@@ -246,6 +274,9 @@ func normalGlob(dir, matchedPath, pattern string,
 	return nil
 }
 
+// headOfPath separates the path into the head and the tail, the head is either the meta-free leading dirs,
+// or the first dir that has meta characters. The escaped characters (by '\', except on Windows) are not meta.
+//
 func headOfPath(path string) (head, tail string, hasMeta bool, slashes int) {
 	nLen := len(path)
 
@@ -255,16 +286,18 @@ func headOfPath(path string) (head, tail string, hasMeta bool, slashes int) {
 	}
 	from := i
 
-	for mark := -1; i < nLen; i++ {
-		switch path[i] {
-		case '*', '?', '[':
-			hasMeta = true
+	for mark := -1; i < nLen; {
+		c, escaped, next := patternChar(path, i)
 
-			if mark >= 0 {
+		switch {
+		case isMetaChar(c, escaped):
+			if mark >= 0 { // The meta-free leading dirs
 				head, tail = path[from:mark], path[mark:]
 				return
 			}
-		case '/':
+
+			hasMeta = true
+		case c == '/':
 			mark = i
 
 			if hasMeta {
@@ -274,6 +307,8 @@ func headOfPath(path string) (head, tail string, hasMeta bool, slashes int) {
 
 			slashes++
 		}
+
+		i = next
 	}
 
 	head, tail = path[from:i], path[i:]
@@ -281,27 +316,61 @@ func headOfPath(path string) (head, tail string, hasMeta bool, slashes int) {
 }
 
 // anyDirsGlob globs the lastest any-dirs' pattern.
+// The entry of the dir may be nil if it is unknown (for the base dir that the term begins with),
+// the stack has the dirs being descended by the term (excluding the dir), to skip the cycles.
+// Only the leaves are reported, unless the helper configures the dirs to be reported (by the TrailingDirs option).
 //
-func anyDirsGlob(dir, matchedPath string,
+func anyDirsGlob(dir, matchedPath string, entry fs.DirEntry, stack *dirStack,
 	helper pathHelper, matches matchesHandler) error {
 
-	names, err := helper.getNames(dir)
-	if err != nil {
-		return matches.onError(matchedPath, err)
+	var entries []fs.DirEntry
+	var err error
+
+	if mayBeDir(entry) && !stack.isCycle(dir, entry, helper) {
+		entries, err = helper.getEntries(dir)
+		if err != nil {
+			return matches.onError(newGlobError(OpReadDir, matchedPath, dir, err))
+		}
 	}
 
-	if len(names) == 0 && isValidMatched(matchedPath) {
-		return matches.onMatched(matchedPath)
+	report := isValidMatched(matchedPath)
+
+	if len(entries) == 0 { // A leaf, reported in any order
+		if report {
+			return matches.onMatched(matchedPath, entry)
+		}
+		return nil
 	}
 
-	for _, name := range names {
+	order, withBase := helper.trailingDirs()
+	if entry == nil && !withBase { // The base dir
+		report = false
+	}
+
+	if report && order == PreOrder {
+		if err = matches.onMatched(matchedPath, entry); err != nil {
+			return err
+		}
+	}
+
+	stack.push(dir)
+	for _, e := range entries {
+		name := e.Name()
 		d, p := appendDir(dir, name, false), appendPath(matchedPath, name)
-		err = anyDirsGlob(d, p, helper, matches)
+		err = anyDirsGlob(d, p, e, stack, helper, matches)
 		if err != nil {
 			break
 		}
 	}
+	stack.pop()
 
+	if err != nil {
+		return err
+	}
+
+	if report && order == PostOrder {
+		err = matches.onMatched(matchedPath, entry)
+	}
 	return err
 }
 
@@ -344,6 +413,7 @@ func appendPath(path, name string) string {
 // normalizePath normalizes the pattern and root.
 //
 func normalizePath(pattern, root string) (string, string) {
+	pattern = plainSeparators(pattern)
 
 	nLen := len(filepath.VolumeName(pattern))
 	if nLen > 0 { // Use the pattern's root to replace the given root
@@ -400,19 +470,20 @@ func normalizePath(pattern, root string) (string, string) {
 func skipDotsDir(pattern string, nLen int) (mark int) {
 	mark = -1
 	i := 0
-	for ; i < nLen; i++ {
-		switch pattern[i] {
-		case '\\':
-			if runtime.GOOS != "windows" {
-				return
-			}
-			fallthrough
-		case '/':
+	for i < nLen {
+		c, escaped, next := patternChar(pattern, i)
+
+		switch {
+		case escaped:
+			return
+		case isSeparatorChar(c):
 			mark = i
-		case '.': // Nothing to do, just keeping loop
+		case c == '.': // Nothing to do, just keeping loop
 		default:
 			return
 		}
+
+		i = next
 	}
 
 	if mark != i-1 {
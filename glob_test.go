@@ -2,8 +2,13 @@ package expath
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -11,7 +16,33 @@ type testPathHelper struct {
 	testPath string
 }
 
-func (t testPathHelper) getNames(dir string) (names []string, err error) {
+// testEntry implements the fs.DirEntry interface for the testPathHelper.
+//
+type testEntry struct {
+	name  string
+	isDir bool
+}
+
+func (e testEntry) Name() string {
+	return e.name
+}
+
+func (e testEntry) IsDir() bool {
+	return e.isDir
+}
+
+func (e testEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e testEntry) Info() (fs.FileInfo, error) {
+	return nil, fs.ErrNotExist
+}
+
+func (t testPathHelper) getEntries(dir string) (entries []fs.DirEntry, err error) {
 	testPath := trimPath(t.testPath)
 	dir = trimDir(filepath.ToSlash(dir))
 
@@ -28,14 +59,14 @@ func (t testPathHelper) getNames(dir string) (names []string, err error) {
 		to, _ := scanDirs(testPath, 0, len(testPath), 1)
 		if to > 0 {
 			name := trimPath(testPath[:to])
-			names = append(names, name)
+			entries = append(entries, testEntry{name, to < len(testPath)})
 		}
 	}
 
 	return
 }
 
-func (t testPathHelper) isExist(dir string) (bool, error) {
+func (t testPathHelper) getEntry(dir string) (fs.DirEntry, error) {
 	testPath := trimPath(t.testPath)
 	dir = trimDir(filepath.ToSlash(dir))
 
@@ -43,12 +74,20 @@ func (t testPathHelper) isExist(dir string) (bool, error) {
 	if nLen <= len(testPath) {
 		if dir == testPath[:nLen] {
 			if nLen == len(testPath) || testPath[nLen] == '/' {
-				return true, nil
+				return testEntry{filepath.Base(dir), nLen < len(testPath)}, nil
 			}
 		}
 	}
 
-	return false, nil
+	return nil, nil
+}
+
+func (t testPathHelper) realPath(name string) (string, error) {
+	return name, nil
+}
+
+func (t testPathHelper) trailingDirs() (DirsOrder, bool) {
+	return LeavesOnly, false
 }
 
 func trimDir(dir string) string {
@@ -215,3 +254,181 @@ func TestNormalizePath(t *testing.T) {
 	}
 
 }
+
+// walkMatched returns the paths (based on the root) in the tree matching the pattern, in lexical order.
+//
+func walkMatched(t *testing.T, pattern, root string) (matched []string) {
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == root {
+			return err
+		}
+		rel, _ := filepath.Rel(root, name)
+		if ok, _ := Match(pattern, filepath.ToSlash(rel)); ok {
+			matched = append(matched, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestGlobOverlapping(t *testing.T) {
+	root := makeTestTree(t, "a/b/x/c", "a/x/b", "a/x/y/b", "x/a/x/b", "x/x/x", "b/a/a/b/b", "a/a/a/a", "a/b/a/b/a/b")
+
+	patterns := []string{
+		"a/**/**/b", "**/**/b", "**/*/**/b", "**/x/**/b", "**/x/**/x/*", "**/a/**/b",
+		"**/b", "**/*", "**/x/*", "**/a/a", "/**/x", "**/b/**/b", "a/**/*/**/b",
+		"**/a/a/**/a", "**/a/b/a/**/*", "/**/a/a/**/?", "**/x/a/**/x/a/b",
+		"a/x/b/**", "a/x/b/c", "a/x/b/*", "**/b/x",
+	}
+
+	for _, pattern := range patterns {
+		matches := sortedGlob(t, pattern, root)
+		for i := range matches {
+			matches[i] = strings.TrimPrefix(matches[i], "/")
+		}
+
+		if want := walkMatched(t, pattern, root); !reflect.DeepEqual(matches, want) {
+			t.Errorf("Glob(%#q) = %q want %q", pattern, matches, want)
+		}
+	}
+}
+
+func TestGlobLiteralLeadingDirs(t *testing.T) {
+	root := makeTestTree(t, "a/b/c", "a/b/d/e", "a/x")
+
+	tests := []struct {
+		pattern string
+		matches []string
+	}{
+		{"a/b/*", []string{"a/b/c", "a/b/d"}},
+		{"a/b/c*", []string{"a/b/c"}},
+		{"/a/b/[c]", []string{"/a/b/c"}},
+		{"a/b/d/?", []string{"a/b/d/e"}},
+		{"a/*/d/*", []string{"a/b/d/e"}},
+	}
+
+	for _, tt := range tests {
+		if matches := sortedGlob(t, tt.pattern, root); !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("Glob(%#q) = %q want %q", tt.pattern, matches, tt.matches)
+		}
+	}
+}
+
+func TestGlobSymlinkCycle(t *testing.T) {
+	root := makeTestTree(t, "a/b/c.txt", "d.txt")
+	if err := os.Symlink("..", filepath.Join(root, "a", "up")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(".", filepath.Join(root, "a", "b", "self")); err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		pattern string
+		matches []string
+	}{
+		{"**/*.txt", []string{"a/b/c.txt", "d.txt"}},
+		{"**/b/**/*.txt", []string{"a/b/c.txt"}},
+		{"a/**", []string{"a/b/c.txt", "a/b/self", "a/up"}},
+	}
+
+	for _, tt := range tests {
+		if matches := sortedGlob(t, tt.pattern, root); !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("Glob(%#q) = %q want %q", tt.pattern, matches, tt.matches)
+		}
+	}
+}
+
+func TestGlobSymlinkCycles(t *testing.T) {
+	root := makeTestTree(t, "a/x.txt", "b/y.txt")
+	if err := os.Symlink(filepath.Join("..", "b"), filepath.Join(root, "a", "l1")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(filepath.Join("..", "a"), filepath.Join(root, "b", "l2")); err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		pattern string
+		matches []string
+	}{
+		{"**/*.txt", []string{"a/l1/y.txt", "a/x.txt", "b/l2/x.txt", "b/y.txt"}},
+		{"**/y.txt", []string{"a/l1/y.txt", "b/y.txt"}},
+		{"a/**", []string{"a/l1/l2", "a/l1/y.txt", "a/x.txt"}},
+
+		// Each of the any-dirs' terms descends from the dir matched before it, so the cycle is entered once more.
+		{"**/l1/**/*.txt", []string{"a/l1/l2/x.txt", "a/l1/y.txt", "b/l2/l1/l2/x.txt", "b/l2/l1/y.txt"}},
+	}
+
+	for _, tt := range tests {
+		if matches := sortedGlob(t, tt.pattern, root); !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("Glob(%#q) = %q want %q", tt.pattern, matches, tt.matches)
+		}
+	}
+}
+
+// countingPathHelper counts the calls of the filePathHelper, that is, the file system calls.
+//
+type countingPathHelper struct {
+	filePathHelper
+	calls int
+}
+
+func (h *countingPathHelper) getEntries(dir string) ([]fs.DirEntry, error) {
+	h.calls++
+	return h.filePathHelper.getEntries(dir)
+}
+
+func (h *countingPathHelper) getEntry(name string) (fs.DirEntry, error) {
+	h.calls++
+	return h.filePathHelper.getEntry(name)
+}
+
+// makeBenchTree makes a tree of 6x6x6 dirs, each of which has 8 files, and the go.mod and .git/config in each of the top dirs.
+//
+func makeBenchTree(b *testing.B) string {
+	var names []string
+	for i := 0; i < 6; i++ {
+		top := fmt.Sprintf("d%d", i)
+		names = append(names, top+"/go.mod", top+"/.git/config")
+
+		for j := 0; j < 6; j++ {
+			for k := 0; k < 6; k++ {
+				for f := 0; f < 8; f++ {
+					names = append(names, fmt.Sprintf("%s/e%d/f%d/file%d.go", top, j, k, f))
+				}
+			}
+		}
+	}
+	return makeTestTree(b, names...)
+}
+
+func benchmarkGlob(b *testing.B, pattern string, matches int) {
+	root := makeBenchTree(b)
+	b.ResetTimer()
+
+	var calls int
+	for i := 0; i < b.N; i++ {
+		var helper countingPathHelper
+		var mh matchedSet
+
+		if err := newGlobOptions(nil).glob(pattern, root, &helper, &mh); err != nil {
+			b.Fatal(err)
+		}
+		if len(mh.matches) != matches {
+			b.Fatalf("Glob(%#q) = %d matches want %d", pattern, len(mh.matches), matches)
+		}
+		calls = helper.calls
+	}
+	b.ReportMetric(float64(calls), "calls/op")
+}
+
+// The any-dirs' term lists each of the 265 dirs once (including the .git dirs), whatever the segment following it.
+//
+func BenchmarkGlobAnyDirs(b *testing.B)     { benchmarkGlob(b, "**/go.mod", 6) }
+func BenchmarkGlobAnyDirsPath(b *testing.B) { benchmarkGlob(b, "**/.git/config", 6) }
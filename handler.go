@@ -1,15 +1,21 @@
 package expath
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 )
 
 // matchesHandler used by the glob routine to handle the matched result.
 // It also acts as a role to decouple handling matched result from the glob algorithm.
 //
+// The entry of the matched file may be nil if it is unknown.
+//
 type matchesHandler interface {
-	onMatched(matched string) error
-	onError(path string, err error) error
+	onMatched(matched string, entry fs.DirEntry) error
+	onError(err *GlobError) error
 	setRoot(root string) error
 }
 
@@ -18,42 +24,73 @@ type matchesHandler interface {
 type matchedSet struct {
 	root    string
 	matches []string
+
+	output  OutputMode
+	absRoot string // the absolute root, for the AbsolutePath output
 }
 
-func (m *matchedSet) onMatched(matched string) error {
-	m.matches = append(m.matches, matched)
+func (m *matchedSet) onMatched(matched string, entry fs.DirEntry) error {
+	m.matches = append(m.matches, m.outputOf(matched))
 	return nil
 }
 
-func (m *matchedSet) onError(path string, err error) error {
+// outputOf returns the matched path in the form of the output.
+//
+func (m *matchedSet) outputOf(matched string) string {
+	switch m.output {
+	case FullPath:
+		return filepath.Join(m.root, filepath.FromSlash(matched))
+	case SlashFullPath:
+		return filepath.ToSlash(filepath.Join(m.root, filepath.FromSlash(matched)))
+	case AbsolutePath:
+		return filepath.Join(m.absRoot, filepath.FromSlash(matched))
+	}
+	return matched
+}
+
+func (m *matchedSet) onError(err *GlobError) error {
 	return err
 }
 
-func (m *matchedSet) setRoot(root string) error {
+func (m *matchedSet) setRoot(root string) (err error) {
 	m.root = root
-	return nil
+	if m.output == AbsolutePath {
+		m.absRoot, err = filepath.Abs(root)
+	}
+	return
 }
 
+// matchedDirs collects the dirs matched by the leading normal segment (before the any-dirs' term),
+// and passes on the errors to the matchesHandler of the glob, so the errors are handled as the others.
+//
+type matchedDirs struct {
+	matchedSet
+	handler matchesHandler
+}
 
-// matchedSet implements the matchesHandler interface to use the GlobFunc to handle the matched results.
+func (m *matchedDirs) onError(err *GlobError) error {
+	return m.handler.onError(err)
+}
+
+// matchesFunc implements the matchesHandler interface to use the GlobFunc to handle the matched results.
 //
 type matchesFunc struct {
 	root   string
 	globFn GlobFunc
 }
 
-func (m *matchesFunc) onMatched(matched string) error {
+func (m *matchesFunc) onMatched(matched string, entry fs.DirEntry) error {
 	var info matchesInfo
-	info.root = m.root
 	info.path = matched
+	info.entry = newGlobEntry(m.root, matched, entry)
 
 	return m.globFn(&info, nil)
 }
 
-func (m *matchesFunc) onError(path string, err error) error {
+func (m *matchesFunc) onError(err *GlobError) error {
 	var info matchesInfo
-	info.root = m.root
-	info.path = path
+	info.path = err.Matched
+	info.entry = newGlobEntry(m.root, err.Matched, nil)
 
 	return m.globFn(&info, err)
 }
@@ -63,15 +100,42 @@ func (m *matchesFunc) setRoot(root string) error {
 	return nil
 }
 
+// matchesFilter wraps a matchesHandler to pass on only the matched files satisfying the predicate.
+//
+type matchesFilter struct {
+	matchesHandler
+	root string
+	pred Predicate
+}
+
+func (m *matchesFilter) onMatched(matched string, entry fs.DirEntry) error {
+	e := newGlobEntry(m.root, matched, entry)
+
+	ok, err := m.pred(e)
+	if err != nil {
+		return m.matchesHandler.onError(newGlobError(OpFilter, matched, e.fullName(), err))
+	}
+	if !ok {
+		return nil
+	}
+
+	return m.matchesHandler.onMatched(matched, e)
+}
+
+func (m *matchesFilter) setRoot(root string) error {
+	m.root = root
+	return m.matchesHandler.setRoot(root)
+}
+
 // matchesInfo implements the GlobInfo interface.
 //
 type matchesInfo struct {
-	root string
-	path string
+	path  string
+	entry *globEntry
 }
 
 func (m *matchesInfo) AtRoot() string {
-	return m.root
+	return m.entry.root
 }
 
 func (m *matchesInfo) Path() string {
@@ -79,10 +143,123 @@ func (m *matchesInfo) Path() string {
 }
 
 func (m *matchesInfo) FullName() string {
-	return appendDirPath(m.root, "", m.path)
+	return m.entry.fullName()
 }
 
 func (m *matchesInfo) FileInfo() (os.FileInfo, error) {
-	fullName := m.FullName()
-	return os.Lstat(fullName)
+	return m.entry.Info()
+}
+
+// globEntry implements the fs.DirEntry interface for a matched file,
+// it caches the file information once retrieved.
+//
+type globEntry struct {
+	root, path string
+	entry      fs.DirEntry // nil if unknown
+
+	info    fs.FileInfo
+	infoErr error
+	hasInfo bool
+}
+
+// newGlobEntry returns the globEntry of the matched path, reusing the entry if it is already a globEntry.
+//
+func newGlobEntry(root, path string, entry fs.DirEntry) *globEntry {
+	if e, ok := entry.(*globEntry); ok {
+		return e
+	}
+	return &globEntry{root: root, path: path, entry: entry}
+}
+
+func (e *globEntry) fullName() string {
+	return appendDirPath(e.root, "", e.path)
+}
+
+func (e *globEntry) Name() string {
+	if e.entry != nil {
+		return e.entry.Name()
+	}
+	return path.Base(e.path)
+}
+
+func (e *globEntry) IsDir() bool {
+	return e.Type().IsDir()
+}
+
+func (e *globEntry) Type() fs.FileMode {
+	if e.entry != nil {
+		return e.entry.Type()
+	}
+
+	info, err := e.Info()
+	if err != nil {
+		return 0
+	}
+	return info.Mode().Type()
+}
+
+func (e *globEntry) Info() (fs.FileInfo, error) {
+	if !e.hasInfo {
+		if e.entry != nil {
+			e.info, e.infoErr = e.entry.Info()
+		} else {
+			e.info, e.infoErr = os.Lstat(e.fullName())
+		}
+		e.hasInfo = true
+	}
+	return e.info, e.infoErr
+}
+
+// errorsPolicy wraps a matchesHandler to ignore or collect the traversal errors, instead of aborting the glob.
+//
+type errorsPolicy struct {
+	matchesHandler
+	ignorePermission bool
+	continueOnError  bool
+	errs             Errors
+}
+
+func (m *errorsPolicy) onError(err *GlobError) error {
+	if m.ignorePermission && errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+
+	e := m.matchesHandler.onError(err)
+	if e != nil && m.continueOnError {
+		if e != error(err) {
+			ge := *err
+			ge.Err = e
+			err = &ge
+		}
+
+		m.errs = append(m.errs, err)
+		return nil
+	}
+	return e
+}
+
+// err returns the collected errors, or nil if there is none.
+//
+func (m *errorsPolicy) err() error {
+	if len(m.errs) == 0 {
+		return nil
+	}
+	return m.errs
+}
+
+// errorsContext wraps a matchesHandler to supply the pattern and the root of the GlobErrors.
+//
+type errorsContext struct {
+	matchesHandler
+	pattern, root string
+}
+
+func (m *errorsContext) onError(err *GlobError) error {
+	err.Pattern, err.Root = m.pattern, m.root
+	return m.matchesHandler.onError(err)
+}
+
+func (m *errorsContext) setRoot(root string) error {
+	m.root = root
+	return m.matchesHandler.setRoot(root)
 }
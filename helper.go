@@ -1,54 +1,168 @@
 package expath
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 )
 
 // pathHelper helps the glob routine to acquire the path information.
 // It also acts as a role to decouple retrieving path information from the glob algorithm.
 //
 type pathHelper interface {
-	getNames(dir string) (names []string, err error)
-	isExist(dir string) (bool, error)
+	getEntries(dir string) (entries []fs.DirEntry, err error)
+	getEntry(name string) (entry fs.DirEntry, err error)
+	realPath(name string) (string, error)
+
+	// trailingDirs returns the order of reporting the dirs matched by the trailing any-dirs' term,
+	// and whether to report the base dir, set by the TrailingDirs option.
+	trailingDirs() (order DirsOrder, withBase bool)
 }
 
 // filePathHelper implements the pathHelper interface by retrieving os file information.
 //
 type filePathHelper struct{}
 
-func (filePathHelper) getNames(dir string) ([]string, error) {
-	fi, err := os.Stat(dir)
+// getEntries returns the entries of the dir, or nil if it isn't exist or isn't a dir.
+// It opens the dir directly (without a Stat of it first), since the dir is mostly known to be a dir by its entry.
+//
+func (filePathHelper) getEntries(dir string) ([]fs.DirEntry, error) {
+	d, err := os.Open(dir)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	defer d.Close()
 
-	if !fi.IsDir() {
-		return nil, nil
+	entries, err := d.ReadDir(-1)
+	if err != nil {
+		if fi, e := d.Stat(); e == nil && !fi.IsDir() {
+			return nil, nil
+		}
 	}
 
-	d, err := os.Open(dir)
+	if entries == nil {
+		entries = []fs.DirEntry{}
+	}
+	return entries, err
+}
+
+// getEntry returns the entry of the named file (without following the symbolic link), or nil if it isn't exist
+// (including that one of its parents isn't a dir).
+//
+func (filePathHelper) getEntry(name string) (fs.DirEntry, error) {
+	fi, err := os.Lstat(name)
 	if err != nil {
+		if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
+			return nil, nil
+		}
 		return nil, err
 	}
-	defer d.Close()
 
-	names, err := d.Readdirnames(-1)
-	if names == nil {
-		names = []string{}
+	return fs.FileInfoToDirEntry(fi), nil
+}
+
+// realPath returns the absolute path of the named file with the symbolic links evaluated.
+//
+func (filePathHelper) realPath(name string) (string, error) {
+	name, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", err
 	}
-	return names, err
+	return filepath.Abs(name)
+}
+
+// trailingDirs returns LeavesOnly, the default.
+//
+func (filePathHelper) trailingDirs() (DirsOrder, bool) {
+	return LeavesOnly, false
+}
+
+// trailingDirsHelper wraps a pathHelper to configure anyDirsGlob to report the dirs, set by the TrailingDirs option.
+// The configuration is passed through the pathHelpers wrapping it.
+//
+type trailingDirsHelper struct {
+	pathHelper
+	order    DirsOrder
+	withBase bool
 }
 
-func (filePathHelper) isExist(dir string) (bool, error) {
-	if _, err := os.Lstat(dir); err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
+func (h *trailingDirsHelper) trailingDirs() (DirsOrder, bool) {
+	return h.order, h.withBase
+}
+
+// mayBeDir reports whether the entry may be a directory (or a symbolic link to a directory),
+// that is, whether it is worth to get its entries. A nil entry (unknown) may be a directory.
+//
+func mayBeDir(entry fs.DirEntry) bool {
+	if entry == nil {
+		return true
+	}
+	return entry.IsDir() || entry.Type()&fs.ModeSymlink != 0
+}
+
+// dirStack is the dirs being descended by a recursive traversal (the innermost last), to detect the cycles made
+// by the symbolic links, such as a link to one of its ancestors, or the links leading to each other.
+// Descending into a symbolic link whose real path is (or is an ancestor of) the real path of a dir on the stack
+// would reach the same files endlessly. The real paths of the dirs are resolved lazily, only when a link is met.
+//
+type dirStack struct {
+	dirs  []string
+	reals []string // "" if not resolved yet
+}
+
+func (s *dirStack) push(dir string) {
+	s.dirs = append(s.dirs, dir)
+	s.reals = append(s.reals, "")
+}
+
+func (s *dirStack) pop() {
+	n := len(s.dirs) - 1
+	s.dirs, s.reals = s.dirs[:n], s.reals[:n]
+}
+
+// isCycle reports whether descending into the dir d (an entry of the innermost dir) revisits a dir on the stack
+// or one of their ancestors, that is, d is a symbolic link that makes the traversal reach the same files endlessly.
+//
+func (s *dirStack) isCycle(d string, entry fs.DirEntry, helper pathHelper) bool {
+	if entry == nil || entry.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+
+	target, err := helper.realPath(d)
+	if err != nil { // Leave the error to be reported by getEntries
+		return false
+	}
+
+	for i := len(s.dirs) - 1; i >= 0; i-- {
+		if s.reals[i] == "" {
+			real, err := helper.realPath(s.dirs[i])
+			if err != nil {
+				continue
+			}
+			s.reals[i] = real
+		}
+
+		if isAncestorOrSelf(target, s.reals[i]) {
+			return true
 		}
-		return false, err
 	}
+	return false
+}
 
-	return true, nil
+// isAncestorOrSelf reports whether the dir is the path or one of its ancestors.
+//
+func isAncestorOrSelf(dir, path string) bool {
+	if len(dir) == len(path) {
+		return dir == path
+	}
+	if !strings.HasPrefix(path, dir) {
+		return false
+	}
+	return isDirSeparator(dir, len(dir)-1) || isDirSeparator(path, len(dir))
 }
@@ -5,6 +5,19 @@ import (
 	"runtime"
 )
 
+// matchPattern matches the name against the segments of the whole pattern.
+//
+func matchPattern(segs []patternSeg, name string) (matched bool, err error) {
+	switch len(segs) {
+	case 1:
+		return matchASeg(segs[0], name)
+	case 0:
+		return matchASeg(patternSeg{"", 0}, name)
+	default:
+		return matchSegs(segs, name, nil)
+	}
+}
+
 // matchASeg used to optimize the match routine if there is only one segment of the whole pattern,
 // the whole pattern is either normal pattern or just any-dirs' pattern.
 //
@@ -57,128 +70,207 @@ func isDirSeparator(name string, i int) bool {
 	return false
 }
 
+// segSpan records where a normal pattern segment matched in the name, used to retrieve the captures.
+//
+type segSpan struct {
+	seg      int    // index of the segment
+	pattern  string // the (maybe trimmed) pattern of the segment that matched
+	from, to int
+}
+
 // matchSegs is main routine to match each pattern segment.
+// If spans is not nil, the spans of the matched normal segments are appended to it.
+//
+func matchSegs(segs []patternSeg, name string, spans *[]segSpan) (matched bool, err error) {
+	// assert len(segs) > 1
+	m := segsMatcher{segs: segs, name: name}
+	if spans != nil {
+		m.spans = make([]segSpan, len(segs))
+	}
+
+	matched, err = m.match()
+
+	if matched && spans != nil {
+		for i, span := range m.spans {
+			if segs[i].dirs >= 0 {
+				*spans = append(*spans, span)
+			}
+		}
+	}
+	return
+}
+
+// segsMatcher matches the name against the segments that separated by the any-dirs' segments.
+//
+// It searches the dirs matched by each any-dirs' segment depth-first, trying the fewer dirs first,
+// and memoizes the failed states (the segment and the position in the name), so each state is searched
+// at most once. For a pattern of k segments and a name of n dirs, at most k×n states are searched,
+// each tries at most n positions for its segment, so the time is bounded by O(k × n² × m)
+// (where m is the cost of filepath.Match for a segment), instead of being exponential in the number
+// of the any-dirs' terms, such as `**/a/**/a/**/a/**/b` on the deep paths with repeated names.
+//
+type segsMatcher struct {
+	segs   []patternSeg
+	name   string
+	failed []bool    // indexed by state: segment*(len(name)+1) + position
+	spans  []segSpan // the spans of the (last) searched path, indexed by segment; nil if not required
+	steps  int       // the number of the tried positions, for testing the bounds
+
+	tracing bool   // whether to trace the tries, for Explain
+	trace   []Step // the traced tries in order
+}
+
+// match matches the whole name against the segments (more than one).
 //
-func matchSegs(segs []patternSeg, name string) (matched bool, err error) {
+func (m *segsMatcher) match() (matched bool, err error) {
+	segs, name := m.segs, m.name
+
 	nLen := len(name)
 	if nLen <= 0 {
+		m.traceFail(0, 0, "empty name")
 		return
 	}
 
-	var i, from, to int
-	var mark int
-
-	segsLen := len(segs)
-	// assert segsLen > 1
-
 	seg := segs[0]
-	pattern := seg.pattern
 
 	if seg.dirs < 0 {
-		if pattern[0] == '*' {
-			if isDirSeparator(name, 0) {
-				from++
-			}
-		} else {
-			if isDirSeparator(name, 0) {
-				from++
-			} else {
-				return
-			}
-		}
-	} else {
-		if pattern[0] != name[0] {
+		from := 0
+		if isDirSeparator(name, 0) {
+			from++
+		} else if seg.pattern[0] != '*' {
+			m.traceFail(0, 0, "the rooted pattern requires the rooted name")
 			return
 		}
 
-		if to, mark = scanDirs(name, from, nLen, seg.dirs); mark < 0 {
-			return
-		}
+		return m.matchFrom(1, from)
+	}
 
-		if mark == 0 && isSegLastAndAny(segs, 1, segsLen) {
-			pattern = pattern[:len(pattern)-1]
-		}
-		if matched, err = filepath.Match(pattern, name[from:to]); !matched {
-			return
+	if isDirSeparator(seg.pattern, 0) != isDirSeparator(name, 0) {
+		if isDirSeparator(name, 0) {
+			m.traceFail(0, 0, "the rooted name requires the rooted pattern")
+		} else {
+			m.traceFail(0, 0, "the rooted pattern requires the rooted name")
 		}
-
-		from = to
-
-		seg = segs[1]
-		// assert seg.dirs < 0
-
-		i++
+		return
 	}
 
-	i++
-	for {
-		if i < segsLen {
-			to, matched, err = searchMatched(segs, i, segsLen, name, from, nLen)
-			if !matched {
-				return
-			}
-
-			from = to
-			i++
-
-			if i >= segsLen {
-				if from >= nLen {
-					return true, nil
-				}
-				break
-			}
-
-			seg = segs[i]
-			// assert seg.dirs < 0
+	to, mark := scanDirs(name, 0, nLen, seg.dirs)
+	if mark < 0 {
+		m.traceFail(0, 0, "too few dirs in the name")
+		return
+	}
 
-			i++
-		} else {
-			if from >= nLen {
-				from-- // To check the last dir separator
-			}
-			return matchAnyDirs(seg.pattern, name[from:])
-		}
+	pattern := m.segPattern(0, mark)
+	matched, err = filepath.Match(pattern, name[:to])
+	m.traceTry(0, pattern, 0, 0, to, matched)
+	if !matched {
+		return
 	}
+	m.record(0, pattern, 0, to)
 
-	return
+	// assert segs[1].dirs < 0
+	return m.matchFrom(2, to)
 }
 
-func searchMatched(segs []patternSeg, i, segsLen int, name string, from, nLen int) (to int, matched bool, err error) {
-	seg := segs[i]
-	// assert seg.dirs > 0
+// matchFrom matches the segments from the i-th one (which follows an any-dirs' segment),
+// against the name from the position (the start of a dir).
+//
+func (m *segsMatcher) matchFrom(i, from int) (bool, error) {
+	segs, name := m.segs, m.name
+	segsLen, nLen := len(segs), len(name)
 
-	origin := from
+	if i >= segsLen { // The last segment is the any-dirs' pattern
+		if from >= nLen {
+			from-- // To check the last dir separator
+		}
+		matched, err := matchAnyDirs(segs[i-1].pattern, name[from:])
+		m.traceTry(i-1, segs[i-1].pattern, from, from, nLen, matched)
+		return matched, err
+	}
 
-	var mark int
+	state := i*(nLen+1) + from
+	if m.failed == nil {
+		m.failed = make([]bool, segsLen*(nLen+1))
+	} else if m.failed[state] {
+		m.traceFail(i, from, "already failed from here")
+		return false, nil
+	}
 
-	if to, mark = scanDirs(name, from, nLen, seg.dirs); mark < 0 {
-		return
+	at := from
+	to, mark := scanDirs(name, at, nLen, segs[i].dirs)
+	if mark < 0 {
+		m.traceFail(i, from, "too few dirs in the name")
 	}
 
-	pattern := seg.pattern
+	for mark >= 0 {
+		m.steps++
 
-	for {
-		if mark == 0 && isSegLastAndAny(segs, i+1, segsLen) {
-			pattern = pattern[:len(pattern)-1]
+		pattern := m.segPattern(i, mark)
+		matched, err := filepath.Match(pattern, name[at:to])
+		if err != nil {
+			return false, err
 		}
-		matched, err = filepath.Match(pattern, name[from:to])
+		m.traceTry(i, pattern, from, at, to, matched)
 
 		if matched {
-			return
-		} else if err != nil {
-			break
+			m.record(i, pattern, at, to)
+
+			if i+1 >= segsLen {
+				if to >= nLen {
+					return true, nil
+				}
+				m.traceFail(i, to, "the last segment doesn't reach the end of the name")
+			} else if matched, err = m.matchFrom(i+2, to); matched || err != nil {
+				return matched, err
+			}
 		}
 
 		// move next
-		from, _ = scanDirs(name, from, nLen, 1)
+		at, _ = scanDirs(name, at, nLen, 1)
+		to, mark = scanDirs(name, to, nLen, 1)
+	}
+
+	m.failed[state] = true
+	return false, nil
+}
 
-		if to, mark = scanDirs(name, to, nLen, 1); mark < 0 {
-			break
+// segPattern returns the pattern of the i-th segment to match,
+// the trailing separator is trimmed if the segment matches to the end of the name (mark == 0),
+// and it is followed by the last any-dirs' segment, such as "abc/" of "abc/**" matching "abc".
+//
+func (m *segsMatcher) segPattern(i, mark int) string {
+	pattern := m.segs[i].pattern
+	if mark == 0 && isSegLastAndAny(m.segs, i+1, len(m.segs)) {
+		pattern = pattern[:len(pattern)-1]
+	}
+	return pattern
+}
+
+// traceTry traces the try of the i-th segment (the pattern) on the name[at:to],
+// the dirs name[from:at] are taken by the preceding any-dirs' segment (if any).
+//
+func (m *segsMatcher) traceTry(i int, pattern string, from, at, to int, matched bool) {
+	if m.tracing {
+		step := Step{Segment: i, Pattern: pattern, From: at, To: to, Matched: matched}
+		if i > 0 && m.segs[i-1].dirs < 0 {
+			step.AnyDirs = m.name[from:at]
 		}
+		m.trace = append(m.trace, step)
 	}
+}
 
-	to = origin
-	return
+// traceFail traces the failure of the i-th segment at the position of the name, which isn't a failed try.
+//
+func (m *segsMatcher) traceFail(i, at int, reason string) {
+	if m.tracing {
+		m.trace = append(m.trace, Step{Segment: i, Pattern: m.segs[i].pattern, From: at, To: at, Reason: reason})
+	}
+}
+
+func (m *segsMatcher) record(i int, pattern string, from, to int) {
+	if m.spans != nil {
+		m.spans[i] = segSpan{i, pattern, from, to}
+	}
 }
 
 func scanDirs(name string, from, len, dirs int) (int, int) {
@@ -1,6 +1,8 @@
 package expath
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -168,6 +170,12 @@ var matchTests = []MatchTest{
 	{"/**/abc/**/def/**", "a/abc/c/d/def/f/", false, nil},
 	{"/**/abc/**/def/**/", "a/abc/c/d/def/f/", false, nil},
 	{"**/abc/**/def/**/", "a/abc/c/d/def/f/", true, nil},
+
+	{"*/**/c", "a/b/c", true, nil}, // --- the leading segment isn't literal
+	{"?/**/c", "a/c", true, nil},
+	{"[a]/**/c", "a/b/c", true, nil},
+	{"*/**/c", "/a/b/c", false, nil},
+	{"/*/**/c", "/a/b/c", true, nil},
 }
 
 func TestMatch(t *testing.T) {
@@ -255,3 +263,84 @@ func TestMatchAnyDirs(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchSegs(t *testing.T) {
+	matchTests := []MatchTest{
+		{"**/abc/", "abc/x", false, nil},
+		{"**/abc/", "abc/x/abc/", true, nil},
+		{"**/*/", "a/b", false, nil},
+		{"**/*/", "a/b/", true, nil},
+		{"a/**/b/", "a/b/c", false, nil},
+		{"*/**", "a/b/c", true, nil},
+		{"?/**/c", "a/b/c", true, nil},
+		{"[a]/**", "a/b/c", true, nil},
+		{"*/**", "/a/b", false, nil},
+
+		{"**/a/**/a/**/a/**/b", "a/a/a/b", true, nil},
+		{"**/a/**/a/**/a/**/b", "x/a/y/a/z/a/w/b", true, nil},
+		{"**/a/**/a/**/a/**/b", "a/a/a/a/a/a", false, nil},
+		{"a/**/b/**/c", "a/b/b/b/c", true, nil},
+		{"a/**/b/**/c", "a/b/b/b/d", false, nil},
+
+		{"**/[", "a/b", false, filepath.ErrBadPattern},
+	}
+
+	for _, tt := range matchTests {
+		ok, err := Match(tt.pattern, tt.s)
+		if ok != tt.matched || err != tt.err {
+			t.Errorf("Match(%#q, %#q) = %v, %q want %v, %q", tt.pattern, tt.s, ok, errp(err), tt.matched, errp(tt.err))
+		}
+	}
+}
+
+// pathologicalCase returns a pattern with k any-dirs' terms and a name of n repeated dirs that never matches.
+//
+func pathologicalCase(k, n int) (pattern, name string) {
+	pattern = strings.Repeat("**/a/", k) + "**/b"
+	name = strings.TrimSuffix(strings.Repeat("a/", n), "/")
+	return
+}
+
+func TestMatchSegsBounds(t *testing.T) {
+	for _, k := range []int{2, 4, 8} {
+		for _, n := range []int{8, 16, 32, 64} {
+			pattern, name := pathologicalCase(k, n)
+
+			segs, _ := scanSegments(pattern)
+			m := segsMatcher{segs: segs, name: name}
+
+			if ok, err := m.matchFrom(1, 0); ok || err != nil {
+				t.Fatalf("Match(%#q, %#q) = %v, %q want false", pattern, name, ok, errp(err))
+			}
+
+			// Each state (segment, dir) tries at most n positions.
+			bound := len(segs) * n * n
+			if m.steps > bound {
+				t.Errorf("Match(%#q, %d dirs) tried %d positions, more than %d", pattern, n, m.steps, bound)
+			}
+		}
+	}
+}
+
+func benchmarkMatchPathological(b *testing.B, k, n int) {
+	pattern, name := pathologicalCase(k, n)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Match(pattern, name)
+	}
+}
+
+func BenchmarkMatchPathologicalK4N16(b *testing.B)  { benchmarkMatchPathological(b, 4, 16) }
+func BenchmarkMatchPathologicalK4N32(b *testing.B)  { benchmarkMatchPathological(b, 4, 32) }
+func BenchmarkMatchPathologicalK4N64(b *testing.B)  { benchmarkMatchPathological(b, 4, 64) }
+func BenchmarkMatchPathologicalK8N16(b *testing.B)  { benchmarkMatchPathological(b, 8, 16) }
+func BenchmarkMatchPathologicalK8N32(b *testing.B)  { benchmarkMatchPathological(b, 8, 32) }
+func BenchmarkMatchPathologicalK8N64(b *testing.B)  { benchmarkMatchPathological(b, 8, 64) }
+func BenchmarkMatchPathologicalK16N64(b *testing.B) { benchmarkMatchPathological(b, 16, 64) }
+
+func BenchmarkMatchTypical(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Match("src/**/test/*.go", "src/pkg/a/b/test/x_test.go")
+	}
+}
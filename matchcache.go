@@ -0,0 +1,210 @@
+package expath
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+)
+
+// MatchCache memoizes the results of matching the names against the compiled Patterns,
+// for the hot patterns that are matched against the same names repeatedly (such as the request paths).
+// The results are the same as Pattern.Match's, keyed by the source text of the pattern and the name.
+// The cached results are bounded, the least recently used ones are dropped first,
+// and the hits and misses are counted for each pattern.
+// A MatchCache is sharded to be safe and scalable for concurrent use by multiple goroutines.
+//
+type MatchCache struct {
+	seed   maphash.Seed
+	shards []matchCacheShard
+
+	stats sync.Map // of *matchCounters, keyed by the source text of the pattern
+}
+
+const maxMatchCacheShards = 16
+
+type matchCacheShard struct {
+	maxNames int
+
+	mu      sync.Mutex
+	results map[matchKey]*list.Element // of *cachedMatch
+	lru     list.List                  // the front is the most recently used
+}
+
+type matchKey struct {
+	pattern string
+	name    string
+}
+
+type cachedMatch struct {
+	key     matchKey
+	matched bool
+}
+
+type matchCounters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// MatchStats is the statistics of a pattern matched through the MatchCache.
+//
+type MatchStats struct {
+	Hits   uint64 // the results found in the cache
+	Misses uint64 // the results matched (and then cached)
+}
+
+// A MatchCacheOption configures the MatchCache.
+//
+type MatchCacheOption func(*matchCacheConfig)
+
+type matchCacheConfig struct {
+	maxNames int
+}
+
+// DefaultMaxCachedNames is the default bound of the number of the results cached by a MatchCache.
+//
+const DefaultMaxCachedNames = 1 << 16
+
+// MaxCachedNames returns a MatchCacheOption that bounds the number of the results cached (of all the patterns),
+// n <= 0 for no bound. The bound is divided among the shards exactly, with fewer shards for a small bound,
+// so each of them holds at least one result.
+//
+func MaxCachedNames(n int) MatchCacheOption {
+	return func(c *matchCacheConfig) {
+		c.maxNames = n
+	}
+}
+
+// NewMatchCache returns a new MatchCache configured by the options.
+//
+func NewMatchCache(opts ...MatchCacheOption) *MatchCache {
+	conf := matchCacheConfig{maxNames: DefaultMaxCachedNames}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	n := maxMatchCacheShards
+	if conf.maxNames > 0 && conf.maxNames < n {
+		n = conf.maxNames
+	}
+
+	c := &MatchCache{seed: maphash.MakeSeed(), shards: make([]matchCacheShard, n)}
+	for i := range c.shards {
+		if conf.maxNames > 0 { // The first shards take the remainder
+			c.shards[i].maxNames = conf.maxNames / n
+			if i < conf.maxNames%n {
+				c.shards[i].maxNames++
+			}
+		}
+		c.shards[i].results = make(map[matchKey]*list.Element)
+	}
+	return c
+}
+
+// Match reports whether name matches the pattern, the same as p.Match(name), using the cached result if any.
+//
+func (c *MatchCache) Match(p *Pattern, name string) bool {
+	key := matchKey{p.pattern, name}
+	shard := c.shardOf(key)
+	counters := c.countersOf(p.pattern)
+
+	if matched, ok := shard.get(key); ok {
+		counters.hits.Add(1)
+		return matched
+	}
+
+	counters.misses.Add(1)
+	matched := p.Match(name)
+	shard.put(key, matched)
+
+	return matched
+}
+
+// Stats returns the statistics of each pattern matched through the cache, keyed by the source text of the pattern.
+//
+func (c *MatchCache) Stats() map[string]MatchStats {
+	stats := make(map[string]MatchStats)
+	c.stats.Range(func(k, v interface{}) bool {
+		counters := v.(*matchCounters)
+		stats[k.(string)] = MatchStats{counters.hits.Load(), counters.misses.Load()}
+		return true
+	})
+	return stats
+}
+
+// Clear drops all the cached results and the statistics.
+//
+func (c *MatchCache) Clear() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.results = make(map[matchKey]*list.Element)
+		s.lru.Init()
+		s.mu.Unlock()
+	}
+
+	c.stats.Range(func(k, _ interface{}) bool {
+		c.stats.Delete(k)
+		return true
+	})
+}
+
+// Len returns the number of the cached results.
+//
+func (c *MatchCache) Len() int {
+	n := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		n += len(s.results)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+func (c *MatchCache) shardOf(key matchKey) *matchCacheShard {
+	var h maphash.Hash
+	h.SetSeed(c.seed)
+	h.WriteString(key.pattern)
+	h.WriteByte(0)
+	h.WriteString(key.name)
+	return &c.shards[h.Sum64()%uint64(len(c.shards))]
+}
+
+func (c *MatchCache) countersOf(pattern string) *matchCounters {
+	if v, ok := c.stats.Load(pattern); ok {
+		return v.(*matchCounters)
+	}
+	v, _ := c.stats.LoadOrStore(pattern, new(matchCounters))
+	return v.(*matchCounters)
+}
+
+func (s *matchCacheShard) get(key matchKey) (matched, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.results[key]
+	if !ok {
+		return false, false
+	}
+
+	s.lru.MoveToFront(el)
+	return el.Value.(*cachedMatch).matched, true
+}
+
+func (s *matchCacheShard) put(key matchKey, matched bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.results[key]; ok { // Put by another goroutine meanwhile
+		s.lru.MoveToFront(el)
+		return
+	}
+
+	s.results[key] = s.lru.PushFront(&cachedMatch{key, matched})
+
+	for s.maxNames > 0 && len(s.results) > s.maxNames {
+		el := s.lru.Back()
+		delete(s.results, s.lru.Remove(el).(*cachedMatch).key)
+	}
+}
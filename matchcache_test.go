@@ -0,0 +1,102 @@
+package expath
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMatchCache(t *testing.T) {
+	patterns := []*Pattern{
+		MustCompile("/api/**/*.json"),
+		MustCompile("/static/*/[a-c]*"),
+		MustCompile("/**/./x/../*.html"),
+	}
+	names := []string{
+		"/api/v1/users.json", "/api/users.json", "/api/v1/users.xml",
+		"/static/css/app.css", "/static/js/bundle.js", "/static/a/b/c",
+		"/index.html", "/a/b/index.html", "/a/./b/../c.html", "",
+	}
+
+	cache := NewMatchCache(MaxCachedNames(20))
+
+	// Each goroutine matches all the names twice, so there are hits and evictions under the contention.
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 2; round++ {
+				for _, p := range patterns {
+					for _, name := range names {
+						if got, want := cache.Match(p, name), p.Match(name); got != want {
+							t.Errorf("Match(%#q, %#q) = %v want %v", p, name, got, want)
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := cache.Len(); n == 0 || n > 20 {
+		t.Errorf("Len() = %d want at most 20 results", n)
+	}
+
+	stats := cache.Stats()
+	for _, p := range patterns {
+		s := stats[p.String()]
+		if s.Hits+s.Misses != 8*2*uint64(len(names)) || s.Misses < uint64(len(names)) {
+			t.Errorf("Stats()[%#q] = %+v want %d calls, at least %d misses", p, s, 8*2*len(names), len(names))
+		}
+	}
+
+	cache.Clear()
+	if n, stats := cache.Len(), cache.Stats(); n != 0 || len(stats) != 0 {
+		t.Errorf("Len(), Stats() after Clear = %d, %v want empty", n, stats)
+	}
+}
+
+func TestMatchCacheStats(t *testing.T) {
+	p := MustCompile("src/**/*.go")
+	cache := NewMatchCache()
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 4; j++ {
+			cache.Match(p, fmt.Sprintf("src/%d/%d.go", i, j))
+		}
+	}
+	for i := 0; i < 3; i++ {
+		cache.Match(p, "src/0/0.go")
+	}
+
+	if s, want := cache.Stats()[p.String()], (MatchStats{Hits: 3, Misses: 12}); s != want {
+		t.Errorf("Stats() = %+v want %+v", s, want)
+	}
+	if n := cache.Len(); n != 12 {
+		t.Errorf("Len() = %d want 12", n)
+	}
+}
+
+func TestMaxCachedNames(t *testing.T) {
+	p := MustCompile("src/**/*.go")
+
+	for _, bound := range []int{1, 5, 16, 20, 100} {
+		cache := NewMatchCache(MaxCachedNames(bound))
+		for i := 0; i < 4*bound; i++ {
+			cache.Match(p, fmt.Sprintf("src/%d.go", i))
+		}
+		if n := cache.Len(); n > bound || n == 0 {
+			t.Errorf("MaxCachedNames(%d): Len() = %d want at most %d", bound, n, bound)
+		}
+	}
+
+	// A single result is kept, the most recent one.
+	cache := NewMatchCache(MaxCachedNames(1))
+	cache.Match(p, "src/a.go")
+	cache.Match(p, "src/b.go")
+	cache.Match(p, "src/b.go")
+	if s := cache.Stats()[p.String()]; cache.Len() != 1 || s != (MatchStats{Hits: 1, Misses: 2}) {
+		t.Errorf("MaxCachedNames(1): Len() = %d, Stats() = %+v want 1, {Hits:1 Misses:2}", cache.Len(), s)
+	}
+}
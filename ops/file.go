@@ -0,0 +1,128 @@
+package ops
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// copyPath copies the file, the symbolic link or the whole directory src to dst.
+//
+func copyPath(src, dst string, overwrite bool) error {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return copyEntry(src, dst, fi, overwrite)
+	}
+
+	return filepath.WalkDir(src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return copyEntry(name, filepath.Join(dst, rel), fi, overwrite)
+	})
+}
+
+// copyEntry copies a single file, symbolic link or (empty) directory.
+//
+func copyEntry(src, dst string, fi fs.FileInfo, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+
+	switch mode := fi.Mode(); {
+	case mode.IsDir():
+		return os.MkdirAll(dst, mode.Perm())
+
+	case mode&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if overwrite {
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return os.Symlink(target, dst)
+
+	case mode.IsRegular():
+		return copyFile(src, dst, mode.Perm(), overwrite)
+
+	default:
+		return &fs.PathError{Op: "copy", Path: src, Err: errors.New("not a regular file")}
+	}
+}
+
+func copyFile(src, dst string, perm fs.FileMode, overwrite bool) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flag |= os.O_EXCL
+	}
+
+	out, err := os.OpenFile(dst, flag, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := out.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// movePath moves src to dst by renaming, or by copying and then removing if they are on different devices.
+//
+func movePath(src, dst string, overwrite bool) error {
+	if !overwrite {
+		if _, err := os.Lstat(dst); err == nil {
+			return &fs.PathError{Op: "move", Path: dst, Err: fs.ErrExist}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+
+	err := os.Rename(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		if err = copyPath(src, dst, overwrite); err == nil {
+			err = os.RemoveAll(src)
+		}
+	}
+	return err
+}
+
+// removePath removes the file or the whole directory.
+//
+func removePath(name string) error {
+	if _, err := os.Lstat(name); err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
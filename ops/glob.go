@@ -0,0 +1,170 @@
+package ops
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/chinmobi/expath"
+)
+
+// An Option configures the planning of the file operations.
+//
+type Option func(*options)
+
+type options struct {
+	overwrite   bool
+	globOptions []expath.GlobOption
+}
+
+// Overwrite makes the Copy and Move operations replace the existing destination files,
+// otherwise they fail for the existing destinations.
+//
+func Overwrite() Option {
+	return func(o *options) {
+		o.overwrite = true
+	}
+}
+
+// GlobOptions passes the options to the glob routine, such as expath.Filter.
+//
+func GlobOptions(opts ...expath.GlobOption) Option {
+	return func(o *options) {
+		o.globOptions = append(o.globOptions, opts...)
+	}
+}
+
+// PlanCopy plans to copy the files matching the pattern under the srcRoot into the dstRoot,
+// preserving their relative structure under the pattern's base directory.
+// For example, copying `assets/**/*.png` from `web` to `dist` copies `web/assets/a/b.png` to `dist/a/b.png`.
+// The matched directories are copied with their whole content.
+//
+func PlanCopy(pattern, srcRoot, dstRoot string, opts ...Option) (*Plan, error) {
+	return plan(Copy, pattern, srcRoot, dstRoot, opts)
+}
+
+// PlanMove plans to move the files matching the pattern under the srcRoot into the dstRoot,
+// the same as PlanCopy.
+//
+func PlanMove(pattern, srcRoot, dstRoot string, opts ...Option) (*Plan, error) {
+	return plan(Move, pattern, srcRoot, dstRoot, opts)
+}
+
+// PlanRemove plans to remove the files matching the pattern under the root.
+// The matched directories are removed with their whole content.
+//
+func PlanRemove(pattern, root string, opts ...Option) (*Plan, error) {
+	return plan(Remove, pattern, root, "", opts)
+}
+
+// CopyGlob plans and applies the copy operations, see PlanCopy and Plan.Apply.
+//
+func CopyGlob(pattern, srcRoot, dstRoot string, opts ...Option) (*Plan, error) {
+	return planAndApply(PlanCopy(pattern, srcRoot, dstRoot, opts...))
+}
+
+// MoveGlob plans and applies the move operations, see PlanMove and Plan.Apply.
+//
+func MoveGlob(pattern, srcRoot, dstRoot string, opts ...Option) (*Plan, error) {
+	return planAndApply(PlanMove(pattern, srcRoot, dstRoot, opts...))
+}
+
+// RemoveGlob plans and applies the remove operations, see PlanRemove and Plan.Apply.
+//
+func RemoveGlob(pattern, root string, opts ...Option) (*Plan, error) {
+	return planAndApply(PlanRemove(pattern, root, opts...))
+}
+
+func planAndApply(p *Plan, err error) (*Plan, error) {
+	if err != nil {
+		return p, err
+	}
+	return p, p.Apply()
+}
+
+func plan(action Action, pattern, srcRoot, dstRoot string, opts []Option) (*Plan, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	base := cleanRel(filepath.ToSlash(expath.Base(pattern)))
+
+	p := &Plan{overwrite: o.overwrite}
+
+	err := expath.GlobFn(pattern, srcRoot, func(info expath.GlobInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		op := Op{Action: action, Src: info.FullName()}
+
+		if fi, err := info.FileInfo(); err == nil {
+			op.IsDir = fi.IsDir()
+		}
+
+		if action != Remove {
+			rel, err := expath.Rel(base, cleanRel(info.Path()))
+			if err != nil {
+				return err
+			}
+			op.Dst = filepath.Join(dstRoot, filepath.FromSlash(rel))
+		}
+
+		p.Ops = append(p.Ops, op)
+		return nil
+	}, append(o.globOptions, expath.Confined())...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Ops = pruneNested(p.Ops)
+	return p, nil
+}
+
+// cleanRel cleans the slash-separated path, that is relative to the root even if it begins with '/'.
+//
+func cleanRel(name string) string {
+	return path.Clean("/" + name)[1:]
+}
+
+// pruneNested sorts the ops by the source, and drops the ops whose source is inside a directory op's source,
+// which is already operated with its whole content. The sources are sorted before the ones inside them,
+// but not right before them (such as "b", "b-x", "b/c"), so each op is checked against all the directory ops kept.
+//
+func pruneNested(ops []Op) []Op {
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Src < ops[j].Src
+	})
+
+	pruned := ops[:0]
+	dirs := make(map[string]bool)
+
+	for _, op := range ops {
+		if inDirs(op.Src, dirs) {
+			continue
+		}
+
+		if op.IsDir {
+			dirs[filepath.Clean(op.Src)] = true
+		}
+		pruned = append(pruned, op)
+	}
+	return pruned
+}
+
+// inDirs reports whether any ancestor of the named file is in the dirs.
+//
+func inDirs(name string, dirs map[string]bool) bool {
+	for dir := filepath.Dir(filepath.Clean(name)); ; {
+		if dirs[dir] {
+			return true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
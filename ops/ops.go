@@ -0,0 +1,115 @@
+// Package ops provides the bulk file operations (copy, move and remove) driven by the expath patterns.
+//
+// Each operation is planned first: the pattern is globbed based on the source root, and each matched file
+// is mapped to its destination, preserving the relative structure under the pattern's base directory
+// (see expath.SplitPattern). The Plan can be inspected (a dry run) before being applied.
+// The glob is confined to the source root (see expath.Confined): a pattern leading out of the root,
+// or a symbolic link resolved out of the root, fails the planning with expath.ErrEscapesRoot.
+// Applying a Plan performs every operation, a failed operation doesn't abort the others,
+// all the failures are reported together.
+//
+package ops
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Action is the kind of a file operation.
+//
+type Action int
+
+const (
+	Copy Action = iota
+	Move
+	Remove
+)
+
+func (a Action) String() string {
+	switch a {
+	case Copy:
+		return "copy"
+	case Move:
+		return "move"
+	case Remove:
+		return "remove"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Op is a planned file operation. Dst is empty for the Remove action.
+//
+type Op struct {
+	Action Action
+	Src    string
+	Dst    string
+	IsDir  bool // the Src is a directory, which is operated with its whole content
+}
+
+func (op Op) String() string {
+	if op.Action == Remove {
+		return op.Action.String() + " " + op.Src
+	}
+	return op.Action.String() + " " + op.Src + " -> " + op.Dst
+}
+
+// Plan is the list of planned file operations, in the order they will be performed.
+//
+type Plan struct {
+	Ops []Op
+
+	overwrite bool
+}
+
+// String renders the plan, one operation per line.
+//
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, op := range p.Ops {
+		b.WriteString(op.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Apply performs each operation of the plan in order. A failed operation doesn't abort the others,
+// the failures (each an *OpError) are returned joined by errors.Join (nil if all the operations succeed).
+//
+func (p *Plan) Apply() error {
+	var errs []error
+
+	for _, op := range p.Ops {
+		var err error
+
+		switch op.Action {
+		case Copy:
+			err = copyPath(op.Src, op.Dst, p.overwrite)
+		case Move:
+			err = movePath(op.Src, op.Dst, p.overwrite)
+		case Remove:
+			err = removePath(op.Src)
+		}
+
+		if err != nil {
+			errs = append(errs, &OpError{op, err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// OpError records a failed file operation.
+//
+type OpError struct {
+	Op  Op
+	Err error
+}
+
+func (e *OpError) Error() string {
+	return e.Op.String() + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,204 @@
+package ops
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/chinmobi/expath"
+)
+
+// makeTree makes the files of the slash-separated names under a temp dir, which the ops are free to change.
+//
+func makeTree(t *testing.T, names ...string) string {
+	root := t.TempDir()
+	for _, name := range names {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// listTree lists the files under the root, with the slash-separated relative paths.
+//
+func listTree(t *testing.T, root string) string {
+	var names []string
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(root, name)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func TestPlanCopy(t *testing.T) {
+	src := makeTree(t, "web/assets/a/b.png", "web/assets/c.png", "web/assets/d.svg")
+	dst := filepath.Join(t.TempDir(), "dist")
+
+	plan, err := PlanCopy("web/assets/**/*.png", src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "copy " + filepath.Join(src, "web", "assets", "a", "b.png") + " -> " + filepath.Join(dst, "a", "b.png") + "\n" +
+		"copy " + filepath.Join(src, "web", "assets", "c.png") + " -> " + filepath.Join(dst, "c.png") + "\n"
+	if plan.String() != want {
+		t.Errorf("PlanCopy() = %q want %q", plan.String(), want)
+	}
+	if files := listTree(t, dst); files != "" {
+		t.Errorf("PlanCopy() copied %q", files)
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if files := listTree(t, dst); files != "a/b.png c.png" {
+		t.Errorf("Apply() copied %q", files)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a", "b.png")); string(data) != "web/assets/a/b.png" {
+		t.Errorf("Apply() copied the content %q", data)
+	}
+}
+
+func TestCopyGlobErrors(t *testing.T) {
+	src := makeTree(t, "a/x.txt", "a/y.txt", "a/z.txt")
+	dst := makeTree(t, "y.txt")
+
+	plan, err := CopyGlob("./a/*.txt", src, dst)
+	if len(plan.Ops) != 3 {
+		t.Fatalf("CopyGlob() planned %v", plan.Ops)
+	}
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) || len(err.(interface{ Unwrap() []error }).Unwrap()) != 1 {
+		t.Fatalf("CopyGlob() = %q want one error", err)
+	}
+	if opErr.Op.Dst != filepath.Join(dst, "y.txt") || !errors.Is(err, fs.ErrExist) {
+		t.Errorf("CopyGlob() = %q want the error of y.txt", err)
+	}
+	if files := listTree(t, dst); files != "x.txt y.txt z.txt" {
+		t.Errorf("CopyGlob() copied %q", files)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "y.txt")); string(data) != "y.txt" {
+		t.Errorf("CopyGlob() overwrote y.txt with %q", data)
+	}
+
+	if _, err = CopyGlob("a/*.txt", src, dst, Overwrite()); err != nil {
+		t.Errorf("CopyGlob(Overwrite()) = %q", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "y.txt")); string(data) != "a/y.txt" {
+		t.Errorf("CopyGlob(Overwrite()) didn't overwrite y.txt: %q", data)
+	}
+}
+
+func TestMoveGlob(t *testing.T) {
+	src := makeTree(t, "legacy/a/b.js", "legacy/a/c/d.js", "legacy/e.js", "legacy/f.txt")
+	dst := t.TempDir()
+
+	plan, err := MoveGlob("legacy/*", src, dst, GlobOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Ops) != 3 || !plan.Ops[0].IsDir {
+		t.Errorf("MoveGlob() planned %v", plan.Ops)
+	}
+
+	if files := listTree(t, dst); files != "a/b.js a/c/d.js e.js f.txt" {
+		t.Errorf("MoveGlob() moved %q", files)
+	}
+	if files := listTree(t, src); files != "" {
+		t.Errorf("MoveGlob() left %q", files)
+	}
+}
+
+func TestRemoveGlob(t *testing.T) {
+	root := makeTree(t, "build/a/b.o", "build/a/c.o", "build/d.o", "build/e.c", "f.o")
+
+	plan, err := RemoveGlob("build/**/*.o", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Ops) != 3 {
+		t.Errorf("RemoveGlob() planned %v", plan.Ops)
+	}
+	if files := listTree(t, root); files != "build/e.c f.o" {
+		t.Errorf("RemoveGlob() left %q", files)
+	}
+
+	plan, err = PlanRemove("build", root)
+	if err != nil || len(plan.Ops) != 1 || !plan.Ops[0].IsDir {
+		t.Fatalf("PlanRemove(`build`) = %v, %v", plan, err)
+	}
+	if err = plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if files := listTree(t, root); files != "f.o" {
+		t.Errorf("PlanRemove(`build`).Apply() left %q", files)
+	}
+}
+
+func TestPruneNested(t *testing.T) {
+	// The siblings "b-x" and "b.c" sort between "b" and "b/bz".
+	names := []string{"src/b/bz", "src/b-x", "src/b.c", "src/c"}
+
+	src := makeTree(t, names...)
+	dst := t.TempDir()
+	plan, err := CopyGlob("src/**/b*", src, dst)
+	if err != nil || len(plan.Ops) != 3 {
+		t.Fatalf("CopyGlob() = %v, %v want 3 ops", plan, err)
+	}
+	if files := listTree(t, dst); files != "b-x b.c b/bz" {
+		t.Errorf("CopyGlob() copied %q", files)
+	}
+
+	root := makeTree(t, names...)
+	if plan, err = RemoveGlob("src/**/b*", root); err != nil || len(plan.Ops) != 3 {
+		t.Fatalf("RemoveGlob() = %v, %v want 3 ops", plan, err)
+	}
+	if files := listTree(t, root); files != "src/c" {
+		t.Errorf("RemoveGlob() left %q", files)
+	}
+}
+
+func TestEscapesRoot(t *testing.T) {
+	root := makeTree(t, "a/b.txt")
+
+	for _, pattern := range []string{"../**", "a/../../*", "./../x"} {
+		if _, err := PlanRemove(pattern, filepath.Join(root, "a")); !errors.Is(err, expath.ErrEscapesRoot) {
+			t.Errorf("PlanRemove(%#q) = %v want ErrEscapesRoot", pattern, err)
+		}
+	}
+}
+
+func TestEscapesRootBySymlink(t *testing.T) {
+	dir := makeTree(t, "root/a/b.txt", "outside/secret/keep.txt")
+	root := filepath.Join(dir, "root")
+	if err := os.Symlink(filepath.Join("..", "outside"), filepath.Join(root, "link")); err != nil {
+		t.Skip(err)
+	}
+
+	for _, pattern := range []string{"**/*.txt", "link/**", "*"} {
+		if _, err := RemoveGlob(pattern, root); !errors.Is(err, expath.ErrEscapesRoot) {
+			t.Errorf("RemoveGlob(%#q) = %v want ErrEscapesRoot", pattern, err)
+		}
+	}
+
+	if files := listTree(t, dir); files != "outside/secret/keep.txt root/a/b.txt root/link" {
+		t.Errorf("RemoveGlob() left %q", files)
+	}
+}
@@ -0,0 +1,191 @@
+package expath
+
+// A GlobOption configures the Glob and GlobFn routines.
+//
+type GlobOption func(*globOptions)
+
+// globOptions holds the configuration set by the GlobOptions.
+//
+type globOptions struct {
+	preds []Predicate
+
+	ignorePermission bool
+	continueOnError  bool
+	confined         bool
+	lexicalDots      bool
+	overlay          bool
+	expander         *expander
+
+	output OutputMode
+
+	dirsOrder   DirsOrder
+	withBaseDir bool
+	cache       *DirCache
+}
+
+// OutputMode is the form of the matched paths returned by Glob.
+//
+type OutputMode int
+
+const (
+	// RelativePath is the matched path relative to the atRoot returned by Glob, slash-separated (the default).
+	// It begins with '/' if the pattern does (that is based on the root, not the file system's root).
+	RelativePath OutputMode = iota
+
+	// FullPath is the OS-native path joined with the root (and cleaned), ready to be passed to os.Open.
+	FullPath
+
+	// SlashFullPath is the FullPath with the slash separators.
+	SlashFullPath
+
+	// AbsolutePath is the absolute OS-native path.
+	AbsolutePath
+)
+
+// DirsOrder is the order of reporting the dirs matched by the trailing any-dirs' term ('/**', or the pattern
+// ending with Separator), set by the TrailingDirs option.
+//
+type DirsOrder int
+
+const (
+	// LeavesOnly reports only the leaves: the files and the empty dirs (the default).
+	LeavesOnly DirsOrder = iota
+
+	// PreOrder reports each dir before the files and the dirs under it, such as for creating a mirror.
+	PreOrder
+
+	// PostOrder reports each dir after the files and the dirs under it, such as for removing them.
+	PostOrder
+)
+
+// TrailingDirs returns a GlobOption that reports the non-empty dirs matched by the trailing any-dirs' term
+// as well as the leaves, in the order. The non-empty base dir (matched before the term, such as "a" of `a/**`)
+// is reported only if withBase, the empty one is a leaf as always. It has no effect for LeavesOnly.
+//
+func TrailingDirs(order DirsOrder, withBase bool) GlobOption {
+	return func(o *globOptions) {
+		o.dirsOrder, o.withBaseDir = order, withBase
+	}
+}
+
+// Filter returns a GlobOption that keeps only the matched files satisfying all the predicates.
+// The predicates are evaluated inside the traversal, before the matched file is reported.
+// Multiple Filter options are combined as All.
+//
+func Filter(preds ...Predicate) GlobOption {
+	return func(o *globOptions) {
+		o.preds = append(o.preds, preds...)
+	}
+}
+
+// ContinueOnError returns a GlobOption that continues the glob when encountering a traversal error
+// (such as reading a directory), instead of aborting the whole glob.
+// The errors are collected and returned as Errors alongside the partial matches.
+//
+// For GlobFn, the GlobFunc is still called for each error first, only the errors it returns are collected.
+//
+func ContinueOnError() GlobOption {
+	return func(o *globOptions) {
+		o.continueOnError = true
+	}
+}
+
+// IgnorePermissionErrors returns a GlobOption that silently skips the files or directories
+// that can't be accessed for the permission (such as EACCES).
+//
+func IgnorePermissionErrors() GlobOption {
+	return func(o *globOptions) {
+		o.ignorePermission = true
+	}
+}
+
+// Output returns a GlobOption that sets the form of the matched paths returned by Glob.
+// It doesn't affect GlobFn, whose GlobInfo supplies both the Path and the FullName.
+//
+func Output(mode OutputMode) GlobOption {
+	return func(o *globOptions) {
+		o.output = mode
+	}
+}
+
+func newGlobOptions(opts []GlobOption) *globOptions {
+	o := new(globOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// glob does the glob routine with the matchesHandler wrapped according to the options.
+//
+func (o *globOptions) glob(pattern, root string, helper pathHelper, matches matchesHandler) error {
+	original := pattern // for the GlobErrors, as the pattern is expanded and cleaned below
+
+	if o.expander != nil {
+		base, rest, err := o.expander.expand(pattern)
+		if err != nil {
+			return &GlobError{Op: OpExpand, Pattern: original, Root: root, Err: err}
+		}
+		if base != "" {
+			if o.confined {
+				return &GlobError{Op: OpConfine, Pattern: original, Root: root, Err: ErrEscapesRoot}
+			}
+			root = base
+		}
+		pattern = rest
+	}
+
+	matches = &errorsContext{matchesHandler: matches, pattern: original}
+
+	var policy *errorsPolicy
+	if o.ignorePermission || o.continueOnError {
+		policy = &errorsPolicy{
+			matchesHandler:   matches,
+			ignorePermission: o.ignorePermission,
+			continueOnError:  o.continueOnError,
+		}
+		matches = policy
+	}
+
+	switch len(o.preds) {
+	case 0:
+	case 1:
+		matches = &matchesFilter{matchesHandler: matches, pred: o.preds[0]}
+	default:
+		matches = &matchesFilter{matchesHandler: matches, pred: All(o.preds...)}
+	}
+
+	if o.lexicalDots {
+		cleaned, err := cleanDots(pattern, true)
+		if err != nil {
+			return &GlobError{Op: OpPattern, Pattern: original, Root: root, Err: err}
+		}
+		pattern = cleaned
+	}
+
+	if o.cache != nil {
+		helper = &cachedPathHelper{pathHelper: helper, cache: o.cache}
+	}
+
+	if o.confined {
+		if err := confinePattern(pattern); err != nil {
+			return &GlobError{Op: OpConfine, Pattern: original, Root: root, Err: err}
+		}
+
+		confined := &confinedPathHelper{pathHelper: helper}
+		helper, matches = confined, &confinedHandler{matchesHandler: matches, helper: confined}
+	}
+
+	if o.dirsOrder != LeavesOnly {
+		helper = &trailingDirsHelper{pathHelper: helper, order: o.dirsOrder, withBase: o.withBaseDir}
+	}
+
+	err := doGlob(pattern, root, helper, matches)
+	if ge, ok := err.(*GlobError); ok && ge.Op == OpPattern { // Of the pattern expanded or cleaned
+		ge.Pattern = original
+	}
+	if err == nil && policy != nil {
+		err = policy.err()
+	}
+	return err
+}
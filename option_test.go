@@ -0,0 +1,97 @@
+package expath
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	root := makeTestTree(t, "x/a/b/c.go", "x/a/d.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		pattern string
+		mode    OutputMode
+		matches []string
+	}{
+		{"a/**/*.go", RelativePath, []string{"a/b/c.go", "a/d.go"}},
+		{"/a/*.go", RelativePath, []string{"/a/d.go"}},
+		{"a/b/*.go", RelativePath, []string{"a/b/c.go"}},
+		{"a/**/*.go", FullPath, []string{filepath.Join("x", "a", "b", "c.go"), filepath.Join("x", "a", "d.go")}},
+		{"/a/*.go", FullPath, []string{filepath.Join("x", "a", "d.go")}},
+		{"../x/a/*.go", FullPath, []string{filepath.Join("x", "a", "d.go")}},
+		{"a/*.go", SlashFullPath, []string{"x/a/d.go"}},
+		{"a/*.go", AbsolutePath, []string{filepath.Join(root, "x", "a", "d.go")}},
+	}
+
+	for _, tt := range tests {
+		matches := sortedGlob(t, tt.pattern, "x", Output(tt.mode))
+		if !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("Glob(%#q, Output(%d)) = %v want %v", tt.pattern, tt.mode, matches, tt.matches)
+		}
+	}
+}
+
+func TestTrailingDirs(t *testing.T) {
+	root := makeTestTree(t, "a/b/c.go", "a/b/d/e.go", "a/f/", "a/g.go")
+
+	tests := []struct {
+		pattern  string
+		order    DirsOrder
+		withBase bool
+		matches  []string
+	}{
+		{"a/**", LeavesOnly, true, []string{"a/b/c.go", "a/b/d/e.go", "a/f", "a/g.go"}},
+		{"a/**", PreOrder, false, []string{"a/b", "a/b/c.go", "a/b/d", "a/b/d/e.go", "a/f", "a/g.go"}},
+		{"a/**", PostOrder, true, []string{"a", "a/b", "a/b/c.go", "a/b/d", "a/b/d/e.go", "a/f", "a/g.go"}},
+		{"a/", PreOrder, true, []string{"a", "a/b", "a/b/c.go", "a/b/d", "a/b/d/e.go", "a/f", "a/g.go"}},
+		{"a/f/**", PreOrder, false, []string{"a/f"}}, // The empty base dir is a leaf
+		{"a/f/**", PreOrder, true, []string{"a/f"}},
+		{"**", PreOrder, true, []string{"a", "a/b", "a/b/c.go", "a/b/d", "a/b/d/e.go", "a/f", "a/g.go"}},
+	}
+
+	for i, tt := range tests {
+		opts := []GlobOption{TrailingDirs(tt.order, tt.withBase)}
+		if i%2 == 1 { // The option isn't lost by the other options wrapping the glob
+			opts = append(opts, Confined(), Cache(NewDirCache()), ContinueOnError(), Filter(Type(File|Dir)))
+		}
+
+		matches, _, err := Glob(tt.pattern, root, opts...)
+		if err != nil {
+			t.Fatalf("Glob(%#q) error: %v", tt.pattern, err)
+		}
+
+		// Each dir is reported before (or after) the paths under it.
+		for i, dir := range matches {
+			for j, m := range matches {
+				under := strings.HasPrefix(m, dir+"/")
+				if under && ((tt.order == PreOrder && j < i) || (tt.order == PostOrder && j > i)) {
+					t.Errorf("Glob(%#q) order %d = %q, %q is out of order", tt.pattern, tt.order, matches, dir)
+				}
+			}
+		}
+
+		sort.Strings(matches)
+		if !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("Glob(%#q) order %d, base %v = %q want %q", tt.pattern, tt.order, tt.withBase, matches, tt.matches)
+		}
+
+		for _, m := range matches {
+			if matched, _ := Match(tt.pattern, m); !matched && tt.pattern != "a/" {
+				t.Errorf("Glob(%#q) = %#q, but it doesn't Match", tt.pattern, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package expath
+
+import (
+	"io/fs"
+	"time"
+)
+
+// A Predicate reports whether a matched file should be kept in the glob results.
+//
+// The entry supplied to the predicate reports the type bits without retrieving the file information,
+// its Info method retrieves the file information (without following the symbolic link) at most once,
+// and the same result is returned by the GlobInfo.FileInfo method.
+//
+type Predicate func(entry fs.DirEntry) (bool, error)
+
+// FileType is the set of the file types used by the Type predicate.
+//
+type FileType uint8
+
+const (
+	File    FileType = 1 << iota // regular files
+	Dir                          // directories
+	Symlink                      // symbolic links (not followed)
+	Other                        // named pipes, sockets, devices and so on
+)
+
+func fileTypeOf(mode fs.FileMode) FileType {
+	switch {
+	case mode.IsRegular():
+		return File
+	case mode.IsDir():
+		return Dir
+	case mode&fs.ModeSymlink != 0:
+		return Symlink
+	default:
+		return Other
+	}
+}
+
+// Type returns a predicate that keeps the files of any of the given types, such as Type(File|Dir).
+// It only uses the type bits of the directory entries, so no extra file information is retrieved.
+//
+func Type(types FileType) Predicate {
+	return func(entry fs.DirEntry) (bool, error) {
+		return fileTypeOf(entry.Type())&types != 0, nil
+	}
+}
+
+// ModifiedAfter returns a predicate that keeps the files modified after the time t.
+//
+func ModifiedAfter(t time.Time) Predicate {
+	return infoPredicate(func(info fs.FileInfo) bool {
+		return info.ModTime().After(t)
+	})
+}
+
+// ModifiedBefore returns a predicate that keeps the files modified before the time t.
+//
+func ModifiedBefore(t time.Time) Predicate {
+	return infoPredicate(func(info fs.FileInfo) bool {
+		return info.ModTime().Before(t)
+	})
+}
+
+// SizeBetween returns a predicate that keeps the files whose size is between min and max (inclusive).
+// A negative max means no upper limit.
+//
+func SizeBetween(min, max int64) Predicate {
+	return infoPredicate(func(info fs.FileInfo) bool {
+		size := info.Size()
+		return size >= min && (max < 0 || size <= max)
+	})
+}
+
+// Mode returns a predicate that keeps the files whose mode has all the bits of the mask set,
+// such as Mode(0111) for the files executable by everyone.
+//
+func Mode(mask fs.FileMode) Predicate {
+	return infoPredicate(func(info fs.FileInfo) bool {
+		return info.Mode()&mask == mask
+	})
+}
+
+// All returns a predicate that keeps the files satisfying all the given predicates.
+// The predicates are evaluated in order, so the cheap ones (such as Type) should go first.
+//
+func All(preds ...Predicate) Predicate {
+	return func(entry fs.DirEntry) (bool, error) {
+		for _, pred := range preds {
+			ok, err := pred(entry)
+			if !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// Any returns a predicate that keeps the files satisfying any of the given predicates.
+//
+func Any(preds ...Predicate) Predicate {
+	return func(entry fs.DirEntry) (bool, error) {
+		for _, pred := range preds {
+			ok, err := pred(entry)
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Not returns a predicate that keeps the files not satisfying the given predicate.
+//
+func Not(pred Predicate) Predicate {
+	return func(entry fs.DirEntry) (bool, error) {
+		ok, err := pred(entry)
+		return !ok && err == nil, err
+	}
+}
+
+func infoPredicate(fn func(info fs.FileInfo) bool) Predicate {
+	return func(entry fs.DirEntry) (bool, error) {
+		info, err := entry.Info()
+		if err != nil {
+			return false, err
+		}
+		return fn(info), nil
+	}
+}
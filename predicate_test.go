@@ -0,0 +1,120 @@
+package expath
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeTestTree makes the files (or the directories if the names end with '/') under a temporary root.
+// The content of each file is its name.
+//
+func makeTestTree(t testing.TB, names ...string) string {
+	root := t.TempDir()
+	for _, name := range names {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func sortedGlob(t *testing.T, pattern, root string, opts ...GlobOption) []string {
+	matches, _, err := Glob(pattern, root, opts...)
+	if err != nil {
+		t.Fatalf("Glob(%#q) error: %v", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+type predicateTest struct {
+	pattern string
+	preds   []Predicate
+	matches string
+}
+
+func TestFilter(t *testing.T) {
+	root := makeTestTree(t, "a/b/c.go", "a/d.go", "a/long.txt", "e/")
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a", "d.go"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(root, "a", "b", "c.go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []predicateTest{
+		{"**", nil, "a/b/c.go a/d.go a/long.txt e"},
+		{"**", []Predicate{Type(File)}, "a/b/c.go a/d.go a/long.txt"},
+		{"**", []Predicate{Type(Dir)}, "e"},
+		{"*", []Predicate{Type(Dir | Symlink)}, "a e"},
+		{"**/*.go", []Predicate{ModifiedAfter(old.Add(time.Minute))}, "a/b/c.go"},
+		{"**/*.go", []Predicate{ModifiedBefore(old.Add(time.Minute))}, "a/d.go"},
+		{"**", []Predicate{Type(File), SizeBetween(7, -1)}, "a/b/c.go a/long.txt"},
+		{"**", []Predicate{Type(File), SizeBetween(0, 8)}, "a/b/c.go a/d.go"},
+		{"**", []Predicate{Type(File), Mode(0111)}, "a/b/c.go"},
+		{"**", []Predicate{Any(Type(Dir), Mode(0100))}, "a/b/c.go e"},
+		{"**", []Predicate{Not(Type(File))}, "e"},
+	}
+
+	for _, tt := range tests {
+		var opts []GlobOption
+		if tt.preds != nil {
+			opts = append(opts, Filter(tt.preds...))
+		}
+
+		matches := strings.Join(sortedGlob(t, tt.pattern, root, opts...), " ")
+		if matches != tt.matches {
+			t.Errorf("Glob(%#q, Filter(...)) = %q want %q", tt.pattern, matches, tt.matches)
+		}
+	}
+}
+
+func TestGlobFnFileInfo(t *testing.T) {
+	root := makeTestTree(t, "a/b.go", "c.go")
+
+	var names []string
+	err := GlobFn("**/*.go", root, func(info GlobInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		fi, err := info.FileInfo()
+		if err != nil {
+			return err
+		}
+		fi2, _ := info.FileInfo()
+		if fi != fi2 {
+			t.Errorf("FileInfo of %#q is not cached", info.Path())
+		}
+		if fi.Size() != int64(len(info.Path())) {
+			t.Errorf("FileInfo(%#q).Size() = %d", info.Path(), fi.Size())
+		}
+
+		names = append(names, info.Path())
+		return nil
+	}, Filter(SizeBetween(1, 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(names)
+	if strings.Join(names, " ") != "a/b.go c.go" {
+		t.Errorf("GlobFn(`**/*.go`) = %q", names)
+	}
+}
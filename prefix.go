@@ -0,0 +1,81 @@
+package expath
+
+import (
+	"path/filepath"
+)
+
+// canMatchUnder reports whether any path under the dir could match the segments of the whole pattern.
+//
+// Only the leading normal segment (before the first any-dirs' term) can rule out the dir:
+// once the dir reaches the any-dirs' term, the term can take all the rest dirs of it.
+// So the leading segment is matched against the dir, by its dirs count:
+//   - If the dir has fewer dirs than the segment, the dir is matched by the leading dirs of the segment.
+//   - Otherwise the segment has to be followed by the any-dirs' term, and match the leading dirs of the dir.
+//
+func canMatchUnder(segs []patternSeg, dir string) bool {
+	if len(segs) == 0 {
+		return false
+	}
+
+	nLen := len(dir)
+	for nLen > 1 && isDirSeparator(dir, nLen-1) {
+		nLen--
+	}
+	dir = dir[:nLen]
+
+	if nLen == 0 || dir == "." {
+		return true
+	}
+	if !isDirSeparator(dir, nLen-1) {
+		dir += "/"
+		nLen++
+	}
+
+	seg := segs[0]
+
+	if seg.dirs < 0 { // "/**" requires the rooted dir
+		return seg.pattern[0] == '*' || isDirSeparator(dir, 0)
+	}
+
+	if rooted := isDirSeparator(dir, 0); rooted != isDirSeparator(seg.pattern, 0) {
+		if !rooted || len(segs) > 1 {
+			return false
+		}
+
+		// The single segment is matched by filepath.Match, which lets the leading stars match the empty dir
+		// before the separator of the rooted dir, such as "*/b" matching "/b".
+		i := 0
+		for i < len(seg.pattern) && seg.pattern[i] == '*' {
+			i++
+		}
+		if i == 0 || i >= len(seg.pattern) || !isDirSeparator(seg.pattern, i) {
+			return false
+		}
+		seg = patternSeg{seg.pattern[i:], seg.dirs - 1}
+	}
+
+	dirs := 0
+	for i := 1; i < nLen; i++ {
+		if isDirSeparator(dir, i) {
+			dirs++
+		}
+	}
+
+	if dirs < seg.dirs { // The dir ends within the segment
+		if dirs == 0 {
+			return true
+		}
+
+		to, _ := scanDirs(seg.pattern, 0, len(seg.pattern), dirs)
+		matched, _ := filepath.Match(seg.pattern[:to], dir)
+		return matched
+	}
+
+	if len(segs) == 1 {
+		return false
+	}
+
+	to, _ := scanDirs(dir, 0, nLen, seg.dirs)
+	matched, _ := filepath.Match(seg.pattern, dir[:to])
+	return matched
+}
@@ -0,0 +1,87 @@
+package expath
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type canMatchUnderTest struct {
+	pattern, dir string
+	can          bool
+}
+
+var canMatchUnderTests = []canMatchUnderTest{
+	{"a/b/*.go", "", true},
+	{"a/b/*.go", ".", true},
+	{"a/b/*.go", "a", true},
+	{"a/b/*.go", "a/", true},
+	{"a/b/*.go", "a/b", true},
+	{"a/b/*.go", "x", false},
+	{"a/b/*.go", "a/x", false},
+	{"a/b/*.go", "a/b/c", false},
+	{"a/*/c/*.go", "a/x", true},
+	{"a/*/c/*.go", "a/x/d", false},
+	{"a/**/*.go", "a/x/y/z", true},
+	{"a/**/*.go", "b/x", false},
+	{"a/b/**", "a/b", true},
+	{"a/b/**", "a/bc", false},
+	{"**/*.go", "x/y", true},
+	{"**/*.go", "/x/y", true},
+	{"/**/*.go", "x/y", false},
+	{"/**/*.go", "/x/y", true},
+	{"/a/*.go", "/", true},
+	{"/a/*.go", "/a", true},
+	{"/a/*.go", "a", false},
+	{"a/*.go", "/a", false},
+	{"*/b", "/b", false},
+	{"*/b/*", "/b", true},
+	{"src/**/test/*.go", "src", true},
+	{"src/**/test/*.go", "src/a/b", true},
+	{"src/**/test/*.go", "vendor", false},
+}
+
+func TestCanMatchUnder(t *testing.T) {
+	for _, tt := range canMatchUnderTests {
+		if can := MustCompile(tt.pattern).CanMatchUnder(tt.dir); can != tt.can {
+			t.Errorf("CanMatchUnder(%#q, %#q) = %v want %v", tt.pattern, tt.dir, can, tt.can)
+		}
+	}
+}
+
+func TestCanMatchUnderWalk(t *testing.T) {
+	root := makeTestTree(t, "src/a/test/x.go", "src/a/b/c.go", "src/test/y.go", "vendor/test/z.go", "docs/test/w.go")
+	p := MustCompile("src/**/test/*.go")
+
+	var visited, matched []string
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == root {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, name)
+		rel = filepath.ToSlash(rel)
+		if p.Match(rel) {
+			matched = append(matched, rel)
+		}
+		if d.IsDir() {
+			visited = append(visited, rel)
+			if !p.CanMatchUnder(rel) {
+				return fs.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantVisited := []string{"docs", "src", "src/a", "src/a/b", "src/a/test", "src/test", "vendor"}
+	if !reflect.DeepEqual(visited, wantVisited) {
+		t.Errorf("visited %q want %q", visited, wantVisited)
+	}
+	if wantMatched := []string{"src/a/test/x.go", "src/test/y.go"}; !reflect.DeepEqual(matched, wantMatched) {
+		t.Errorf("matched %q want %q", matched, wantMatched)
+	}
+}
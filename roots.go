@@ -0,0 +1,119 @@
+package expath
+
+import (
+	"io/fs"
+)
+
+// RootMatch is a file matched by GlobRoots, tagged with the root it came from.
+//
+type RootMatch struct {
+	Root   string // the one of the roots that the file came from
+	AtRoot string // the root that the Path is relative to, the same as the atRoot returned by Glob for the Root
+	Path   string // in the form set by the Output option
+}
+
+// Overlay returns a GlobOption for GlobRoots and GlobRootsFn, that the files matched at the earlier roots
+// shadow the ones matched at the later roots by the relative path, as the layers of an overlay file system.
+// The shadowing is among the files reported, that is after the Filter option.
+// It has no effect on Glob and GlobFn.
+//
+func Overlay() GlobOption {
+	return func(o *globOptions) {
+		o.overlay = true
+	}
+}
+
+// GlobRoots globs the pattern at each of the roots in order, the same as Glob does with the opts,
+// and returns the files matched at all the roots, each tagged with the root it came from.
+//
+// An error aborts the glob, the files matched at the roots before are returned alongside.
+// With ContinueOnError, the Errors of all the roots are collected as one.
+//
+func GlobRoots(pattern string, roots []string, opts ...GlobOption) ([]RootMatch, error) {
+	o := newGlobOptions(opts)
+
+	var matches []RootMatch
+	err := o.globRoots(pattern, roots, func(root string, glob func(matchesHandler) error) error {
+		var mh matchedSet
+		mh.output = o.output
+
+		err := glob(&mh)
+		for _, matched := range mh.matches {
+			matches = append(matches, RootMatch{root, mh.root, matched})
+		}
+		return err
+	})
+
+	return matches, err
+}
+
+// GlobRootsFn uses the GlobFunc callback function to handle each file matched or error encountered
+// at each of the roots in order, the same as GlobFn does with the opts.
+// The GlobInfo.AtRoot reports the root of each file (as the atRoot returned by Glob for the root).
+//
+func GlobRootsFn(pattern string, roots []string, globFn GlobFunc, opts ...GlobOption) error {
+	return newGlobOptions(opts).globRoots(pattern, roots, func(_ string, glob func(matchesHandler) error) error {
+		return glob(&matchesFunc{globFn: globFn})
+	})
+}
+
+// globRoots calls the each function for each of the roots in order, with the glob routine at the root
+// (wrapping the matchesHandler for the Overlay option).
+//
+func (o *globOptions) globRoots(pattern string, roots []string,
+	each func(root string, glob func(matchesHandler) error) error) error {
+
+	var shadowed map[string]bool
+	if o.overlay {
+		shadowed = make(map[string]bool)
+	}
+
+	var errs Errors
+	for _, root := range roots {
+		var overlay *overlayMatches
+
+		err := each(root, func(matches matchesHandler) error {
+			var helper filePathHelper
+			if shadowed != nil {
+				overlay = &overlayMatches{matchesHandler: matches, shadowed: shadowed}
+				matches = overlay
+			}
+			return o.glob(pattern, root, &helper, matches)
+		})
+
+		if overlay != nil {
+			for _, matched := range overlay.matched {
+				shadowed[matched] = true
+			}
+		}
+
+		if rootErrs, ok := err.(Errors); ok {
+			errs = append(errs, rootErrs...)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// overlayMatches wraps a matchesHandler to skip the matched files shadowed by the earlier roots,
+// set by the Overlay option. The files matched at the root shadow the later roots only after the root is done.
+//
+type overlayMatches struct {
+	matchesHandler
+	shadowed map[string]bool
+	matched  []string
+}
+
+func (m *overlayMatches) onMatched(matched string, entry fs.DirEntry) error {
+	if m.shadowed[matched] {
+		return nil
+	}
+
+	m.matched = append(m.matched, matched)
+	return m.matchesHandler.onMatched(matched, entry)
+}
@@ -0,0 +1,154 @@
+package expath
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGlobRoots(t *testing.T) {
+	workspace := makeTestTree(t, "a/b.go", "c.go", "d.txt")
+	generated := makeTestTree(t, "a/b.go", "a/e.go")
+	vendored := makeTestTree(t, "c.go", "f/g.go")
+	roots := []string{workspace, generated, vendored}
+	rootIndex := map[string]int{workspace: 0, generated: 1, vendored: 2}
+
+	tests := []struct {
+		opts []GlobOption
+		want []RootMatch
+	}{
+		{nil, []RootMatch{
+			{workspace, workspace, "a/b.go"},
+			{workspace, workspace, "c.go"},
+			{generated, generated, "a/b.go"},
+			{generated, generated, "a/e.go"},
+			{vendored, vendored, "c.go"},
+			{vendored, vendored, "f/g.go"},
+		}},
+		{[]GlobOption{Overlay()}, []RootMatch{
+			{workspace, workspace, "a/b.go"},
+			{workspace, workspace, "c.go"},
+			{generated, generated, "a/e.go"},
+			{vendored, vendored, "f/g.go"},
+		}},
+		{[]GlobOption{Overlay(), Output(FullPath)}, []RootMatch{
+			{workspace, workspace, filepath.Join(workspace, "a", "b.go")},
+			{workspace, workspace, filepath.Join(workspace, "c.go")},
+			{generated, generated, filepath.Join(generated, "a", "e.go")},
+			{vendored, vendored, filepath.Join(vendored, "f", "g.go")},
+		}},
+	}
+
+	for i, test := range tests {
+		matches, err := GlobRoots("**/*.go", roots, test.opts...)
+		if err != nil {
+			t.Fatalf("#%d GlobRoots() error: %v", i, err)
+		}
+
+		for i := range matches {
+			matches[i].AtRoot = filepath.Clean(matches[i].AtRoot)
+		}
+		sortInRoots(t, matches, rootIndex)
+		if !reflect.DeepEqual(matches, test.want) {
+			t.Errorf("#%d GlobRoots() = %v want %v", i, matches, test.want)
+		}
+	}
+}
+
+func TestGlobRootsOverlayErrors(t *testing.T) {
+	upper := makeTestTree(t, "a.go", "bad.go")
+	lower := makeTestTree(t, "a.go", "b.go", "bad.go")
+	rootIndex := map[string]int{upper: 0, lower: 1}
+
+	bad := Filter(func(entry fs.DirEntry) (bool, error) {
+		if entry.Name() == "bad.go" {
+			return false, errors.New("bad")
+		}
+		return true, nil
+	})
+
+	// The file failed at the upper root isn't reported, so it shadows nothing.
+	matches, err := GlobRoots("*.go", []string{upper, lower}, Overlay(), ContinueOnError(), bad)
+	for i := range matches {
+		matches[i].AtRoot = filepath.Clean(matches[i].AtRoot)
+	}
+	sortInRoots(t, matches, rootIndex)
+	if want := []RootMatch{{upper, upper, "a.go"}, {lower, lower, "b.go"}}; !reflect.DeepEqual(matches, want) {
+		t.Errorf("GlobRoots() = %v want %v", matches, want)
+	}
+
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("GlobRoots() error = %v want the Errors of both roots", err)
+	}
+	for i, root := range []string{upper, lower} {
+		if errs[i].Op != OpFilter || errs[i].Matched != "bad.go" || filepath.Clean(errs[i].Root) != root {
+			t.Errorf("GlobRoots() error #%d = %v want the error of bad.go at %q", i, errs[i], root)
+		}
+	}
+}
+
+// sortInRoots sorts the matches of each root by the path, since the order of the entries of a dir depends on
+// the file system. The roots have to be globbed in order, each as a whole.
+//
+func sortInRoots(t *testing.T, matches []RootMatch, rootIndex map[string]int) {
+	t.Helper()
+
+	for from, i := 0, 1; i <= len(matches); i++ {
+		if i < len(matches) && matches[i].Root == matches[from].Root {
+			continue
+		}
+		if i < len(matches) && rootIndex[matches[i].Root] <= rootIndex[matches[from].Root] {
+			t.Errorf("the matches of %q follow the ones of %q", matches[i].Root, matches[from].Root)
+		}
+
+		block := matches[from:i]
+		sort.Slice(block, func(i, j int) bool { return block[i].Path < block[j].Path })
+		from = i
+	}
+}
+
+func TestGlobRootsFn(t *testing.T) {
+	upper := makeTestTree(t, "x/a.txt")
+	lower := makeTestTree(t, "x/a.txt", "x/b.txt")
+	sub := filepath.Join(lower, "x")
+
+	got := map[string]string{}
+	err := GlobRootsFn("../x/*.txt", []string{filepath.Join(upper, "x"), sub}, func(info GlobInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		got[info.FullName()] = info.AtRoot()
+		return nil
+	}, Overlay())
+	if err != nil {
+		t.Fatalf("GlobRootsFn() error: %v", err)
+	}
+
+	// The '..' is moved into the AtRoot of each root.
+	want := map[string]string{
+		filepath.Join(upper, "x", "a.txt"): filepath.Join(upper, "x", ".."),
+		filepath.Join(lower, "x", "b.txt"): filepath.Join(sub, ".."),
+	}
+	for name, atRoot := range got {
+		if filepath.Clean(atRoot) != filepath.Clean(want[name]) {
+			t.Errorf("AtRoot() of %q = %q want %q", name, atRoot, want[name])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("GlobRootsFn() = %v want %v", got, want)
+	}
+
+	// An error aborts the glob of the rest roots.
+	stop, calls := errors.New("stop"), 0
+	err = GlobRootsFn("*.txt", []string{sub, sub}, func(info GlobInfo, err error) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("GlobRootsFn() error = %v, %d calls want %v, 1 call", err, calls, stop)
+	}
+}
@@ -1,7 +1,9 @@
 package expath
 
 import (
+	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // A patternSeg is a pattern segment of the whole pattern.
@@ -13,9 +15,69 @@ type patternSeg struct {
 	dirs    int
 }
 
+// The escape grammar of the patterns, shared by Match and Glob:
+//
+// Except on Windows, '\\' escapes the following character, which is then a literal character instead of
+// a meta character ('*', '?' or '['). An escaped Separator is still a Separator (the names can't have it),
+// so the escaped Separators are made plain before the pattern is scanned (see plainSeparators).
+// On Windows, '\\' is a Separator, and nothing is escaped.
+// A trailing '\\' escapes nothing, it's malformed (see validatePattern).
+
+// escaping reports whether '\\' escapes in the patterns.
+//
+const escaping = runtime.GOOS != "windows"
+
+// patternChar returns the character of the pattern at i (the escaped one for '\\'), whether it's escaped,
+// and the index following it. All the scanners of the patterns walk through the pattern by it.
+//
+func patternChar(pattern string, i int) (c byte, escaped bool, next int) {
+	c = pattern[i]
+	if c == '\\' && escaping && i+1 < len(pattern) {
+		return pattern[i+1], true, i + 2
+	}
+	return c, false, i + 1
+}
+
+// isSeparatorChar reports whether the character returned by patternChar is a Separator.
+//
+func isSeparatorChar(c byte) bool {
+	return c == '/' || (c == '\\' && !escaping)
+}
+
+// isMetaChar reports whether the character returned by patternChar is a meta character.
+//
+func isMetaChar(c byte, escaped bool) bool {
+	return !escaped && (c == '*' || c == '?' || c == '[')
+}
+
+// plainSeparators replaces the escaped Separators of the pattern with the plain ones.
+//
+func plainSeparators(pattern string) string {
+	if !escaping || !strings.Contains(pattern, `\/`) {
+		return pattern
+	}
+
+	var b strings.Builder
+	b.Grow(len(pattern))
+
+	for i := 0; i < len(pattern); {
+		c, escaped, next := patternChar(pattern, i)
+		if escaped && isSeparatorChar(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(pattern[i:next])
+		}
+		i = next
+	}
+	return b.String()
+}
+
 // scanSegments scans the whole pattern and separates it into segments by the any-dirs' term ('**').
+// The segments are of the pattern with the plain Separators.
 //
 func scanSegments(pattern string) (segs []patternSeg, err error) {
+	pattern = plainSeparators(pattern)
+
 	len := len(pattern)
 	if len == 0 {
 		return
@@ -32,19 +94,15 @@ func scanSegments(pattern string) (segs []patternSeg, err error) {
 	}
 
 	for i < len {
-		switch pattern[i] {
-		case '\\':
-			if runtime.GOOS != "windows" {
-				i++
-				continue
-			}
-			fallthrough
-		case '/':
+		c, _, next := patternChar(pattern, i)
+
+		switch {
+		case isSeparatorChar(c):
 			if i > 0 {
 				dirs++
 			}
 
-			to, ok = scanAnyDirsPattern(pattern, i+1, len, false)
+			to, ok = scanAnyDirsPattern(pattern, next, len, false)
 			if ok || to >= len {
 				if from < i {
 					segs = append(segs, patternSeg{pattern[from : i+1], dirs})
@@ -63,11 +121,11 @@ func scanSegments(pattern string) (segs []patternSeg, err error) {
 
 				from, i = to, to
 			} else {
-				i++
+				i = next
 			}
 
 		default:
-			i++
+			i = next
 		}
 	}
 
@@ -86,16 +144,13 @@ func scanAnyDirsPattern(pattern string, from, len int, preOK bool) (int, bool) {
 		i++
 		if i < len && pattern[i] == '*' {
 			for i++; i < len; {
-				switch pattern[i] {
-				case '*': // more star?
-					i++
-				case '\\':
-					if runtime.GOOS != "windows" {
-						return from, preOK
-					}
-					fallthrough
-				case '/':
-					return scanAnyDirsPattern(pattern, i+1, len, true)
+				c, escaped, next := patternChar(pattern, i)
+
+				switch {
+				case c == '*' && !escaped: // more star?
+					i = next
+				case isSeparatorChar(c):
+					return scanAnyDirsPattern(pattern, next, len, true)
 				default:
 					return from, preOK
 				}
@@ -105,3 +160,27 @@ func scanAnyDirsPattern(pattern string, from, len int, preOK bool) (int, bool) {
 	}
 	return from, preOK
 }
+
+var errBadPattern = filepath.ErrBadPattern
+
+// validatePattern checks the syntax of the whole pattern, filepath.ErrBadPattern is returned if it is malformed.
+// Unlike filepath.Match, which may stop checking when the match fails, it always checks the whole pattern.
+//
+func validatePattern(pattern string) error {
+	for i := 0; i < len(pattern); {
+		c, escaped, next := patternChar(pattern, i)
+
+		switch {
+		case c == '\\' && escaping && !escaped: // The trailing '\\'
+			return errBadPattern
+		case c == '[' && !escaped:
+			_, rest, err := matchClass(pattern[next:], 0)
+			if err != nil {
+				return err
+			}
+			next = len(pattern) - len(rest)
+		}
+		i = next
+	}
+	return validateDotDirs(pattern)
+}
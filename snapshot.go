@@ -0,0 +1,277 @@
+package expath
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// GlobSnapshot is the result of a glob that can be saved (e.g. as JSON) and compared with a later one,
+// to find out the files added, removed or changed in between.
+//
+type GlobSnapshot struct {
+	Pattern  string         `json:"pattern"`
+	Root     string         `json:"root"`
+	AtRoot   string         `json:"atRoot"`
+	WithInfo bool           `json:"withInfo,omitempty"` // whether the files have the size and the modification time
+	Files    []SnapshotFile `json:"files"`              // sorted by the path
+	Dirs     []SnapshotDir  `json:"dirs,omitempty"`     // the dirs read by the glob, sorted by the path
+}
+
+// SnapshotFile is a matched file of the GlobSnapshot, the path is relative to the AtRoot.
+//
+type SnapshotFile struct {
+	Path    string     `json:"path"`
+	Size    int64      `json:"size,omitempty"`
+	ModTime *time.Time `json:"mtime,omitempty"`
+}
+
+// SnapshotDir is a dir read by the glob of the GlobSnapshot, with its modification time and its entries,
+// so that the Diff of the snapshot (even in another process) skips reading the dir if its modification time
+// is unchanged. The path is the dir as read (joined with the Root), slash-separated.
+//
+type SnapshotDir struct {
+	Path    string          `json:"path"`
+	ModTime time.Time       `json:"mtime"`
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+// SnapshotEntry is an entry of the SnapshotDir, the type is the type bits of the file mode.
+//
+type SnapshotEntry struct {
+	Name string      `json:"name"`
+	Type fs.FileMode `json:"type,omitempty"`
+}
+
+// SnapshotDiff is the difference between two GlobSnapshots, each of the paths is sorted.
+// The changed files are the ones in both snapshots but with a different size or modification time,
+// so they are reported only if both snapshots are taken with the information.
+//
+type SnapshotDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// Empty reports whether there is no difference.
+//
+func (d SnapshotDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Snapshot globs the pattern at the root (the same as GlobFn does with the opts), and returns the matched files
+// as a GlobSnapshot. If withInfo, the size and the modification time (not following the symbolic link)
+// of each file are recorded too. The dirs read by the glob are recorded with their modification times.
+// Any file error aborts the snapshot, since a missing file would be reported as removed.
+//
+func Snapshot(pattern, root string, withInfo bool, opts ...GlobOption) (*GlobSnapshot, error) {
+	return snapshot(pattern, root, withInfo, nil, opts)
+}
+
+// snapshot takes the snapshot, reusing the entries of the dirs of the previous snapshot (if any)
+// whose modification times are unchanged.
+//
+func snapshot(pattern, root string, withInfo bool, prev *GlobSnapshot, opts []GlobOption) (*GlobSnapshot, error) {
+	s := &GlobSnapshot{Pattern: pattern, Root: root, WithInfo: withInfo, Files: []SnapshotFile{}}
+
+	// The dirs served by the Cache are recorded too, so the cache is below the recording.
+	o := newGlobOptions(opts)
+	helper := &snapshotPathHelper{pathHelper: filePathHelper{}, read: make(map[string]bool)}
+	if o.cache != nil {
+		helper.pathHelper = &cachedPathHelper{pathHelper: filePathHelper{}, cache: o.cache}
+		o.cache = nil
+	}
+	if prev != nil {
+		helper.prev = make(map[string]*SnapshotDir, len(prev.Dirs))
+		for i := range prev.Dirs {
+			helper.prev[prev.Dirs[i].Path] = &prev.Dirs[i]
+		}
+	}
+
+	var mf matchesFunc
+	mf.globFn = func(info GlobInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		file := SnapshotFile{Path: info.Path()}
+
+		if withInfo {
+			fi, err := info.FileInfo()
+			if err != nil {
+				return err
+			}
+			mtime := fi.ModTime().UTC()
+			file.Size, file.ModTime = fi.Size(), &mtime
+		}
+
+		s.Files = append(s.Files, file)
+		return nil
+	}
+
+	if err := o.glob(pattern, root, helper, &mf); err != nil {
+		return nil, err
+	}
+	s.AtRoot = mf.root
+
+	sort.Slice(s.Files, func(i, j int) bool {
+		return s.Files[i].Path < s.Files[j].Path
+	})
+
+	s.Dirs = helper.dirs
+	sort.Slice(s.Dirs, func(i, j int) bool {
+		return s.Dirs[i].Path < s.Dirs[j].Path
+	})
+	return s, nil
+}
+
+// Diff re-globs the pattern of the previous snapshot at its root (with the same WithInfo), and returns
+// the new snapshot with its difference from the previous one.
+// The dirs whose modification times are unchanged since the previous snapshot (such as one loaded from
+// the last CI run) are not read again, their recorded entries are used instead.
+// The opts are the same as Glob's, passing the same Cache across the Diffs (of a long-running process)
+// also skips reading the changed dirs that have been read since.
+//
+func Diff(prev *GlobSnapshot, opts ...GlobOption) (*GlobSnapshot, SnapshotDiff, error) {
+	s, err := snapshot(prev.Pattern, prev.Root, prev.WithInfo, prev, opts)
+	if err != nil {
+		return nil, SnapshotDiff{}, err
+	}
+	return s, s.Compare(prev), nil
+}
+
+// Compare returns the difference of the snapshot from the previous one, prev == nil means no files before.
+//
+func (s *GlobSnapshot) Compare(prev *GlobSnapshot) SnapshotDiff {
+	d := SnapshotDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+
+	var prevFiles []SnapshotFile
+	withInfo := s.WithInfo
+	if prev != nil {
+		prevFiles = prev.sortedFiles()
+		withInfo = withInfo && prev.WithInfo
+	}
+	files := s.sortedFiles()
+
+	i, j := 0, 0
+	for i < len(files) || j < len(prevFiles) {
+		switch {
+		case j >= len(prevFiles) || (i < len(files) && files[i].Path < prevFiles[j].Path):
+			d.Added = append(d.Added, files[i].Path)
+			i++
+
+		case i >= len(files) || prevFiles[j].Path < files[i].Path:
+			d.Removed = append(d.Removed, prevFiles[j].Path)
+			j++
+
+		default:
+			if withInfo && (files[i].Size != prevFiles[j].Size || !sameTime(files[i].ModTime, prevFiles[j].ModTime)) {
+				d.Changed = append(d.Changed, files[i].Path)
+			}
+			i++
+			j++
+		}
+	}
+
+	return d
+}
+
+// sortedFiles returns the files sorted by the path, in case the snapshot was edited or loaded unsorted.
+//
+func (s *GlobSnapshot) sortedFiles() []SnapshotFile {
+	if sort.SliceIsSorted(s.Files, func(i, j int) bool { return s.Files[i].Path < s.Files[j].Path }) {
+		return s.Files
+	}
+
+	files := append([]SnapshotFile(nil), s.Files...)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// snapshotPathHelper wraps a pathHelper to record the dirs read (with their modification times), and to reuse
+// the entries of the dirs of the previous snapshot whose modification times are unchanged.
+//
+type snapshotPathHelper struct {
+	pathHelper
+	prev map[string]*SnapshotDir
+	read map[string]bool
+	dirs []SnapshotDir
+}
+
+func (h *snapshotPathHelper) getEntries(dir string) ([]fs.DirEntry, error) {
+	fi, err := os.Stat(dir)
+	if err != nil || !fi.IsDir() { // Leave it to the pathHelper
+		return h.pathHelper.getEntries(dir)
+	}
+	key := filepath.ToSlash(filepath.Clean(dir))
+
+	if d, ok := h.prev[key]; ok && d.ModTime.Equal(fi.ModTime()) {
+		h.record(*d)
+		return d.dirEntries(dir), nil
+	}
+
+	entries, err := h.pathHelper.getEntries(dir)
+	if err != nil || entries == nil {
+		return entries, err
+	}
+
+	d := SnapshotDir{Path: key, ModTime: fi.ModTime().UTC(), Entries: make([]SnapshotEntry, len(entries))}
+	for i, entry := range entries {
+		d.Entries[i] = SnapshotEntry{entry.Name(), entry.Type()}
+	}
+	h.record(d)
+
+	return entries, nil
+}
+
+func (h *snapshotPathHelper) record(d SnapshotDir) {
+	if !h.read[d.Path] {
+		h.read[d.Path] = true
+		h.dirs = append(h.dirs, d)
+	}
+}
+
+// dirEntries returns the recorded entries as the entries of the dir.
+//
+func (d *SnapshotDir) dirEntries(dir string) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(d.Entries))
+	for i, e := range d.Entries {
+		entries[i] = &snapshotEntry{dir, e}
+	}
+	return entries
+}
+
+// snapshotEntry implements the fs.DirEntry interface for a recorded entry, its file information is retrieved
+// when required.
+//
+type snapshotEntry struct {
+	dir string
+	SnapshotEntry
+}
+
+func (e *snapshotEntry) Name() string {
+	return e.SnapshotEntry.Name
+}
+
+func (e *snapshotEntry) IsDir() bool {
+	return e.SnapshotEntry.Type.IsDir()
+}
+
+func (e *snapshotEntry) Type() fs.FileMode {
+	return e.SnapshotEntry.Type
+}
+
+func (e *snapshotEntry) Info() (fs.FileInfo, error) {
+	return os.Lstat(filepath.Join(e.dir, e.SnapshotEntry.Name))
+}
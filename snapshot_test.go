@@ -0,0 +1,127 @@
+package expath
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	root := makeTestTree(t, "a/x.proto", "a/b/y.proto", "c/z.proto", "c/w.txt")
+	cache := NewDirCache()
+
+	prev, err := Snapshot("**/*.proto", root, true, Cache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded GlobSnapshot
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Files, prev.Files) || loaded.Pattern != prev.Pattern || !loaded.WithInfo {
+		t.Fatalf("the loaded snapshot = %+v want %+v", loaded, *prev)
+	}
+
+	if _, d, err := Diff(&loaded, Cache(cache)); err != nil || !d.Empty() {
+		t.Errorf("Diff() unchanged = %+v, %v want no difference", d, err)
+	}
+
+	if err = os.WriteFile(filepath.Join(root, "a", "b", "n.proto"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	touchDir(t, filepath.Join(root, "a", "b"))
+	if err = os.Remove(filepath.Join(root, "c", "z.proto")); err != nil {
+		t.Fatal(err)
+	}
+	touchDir(t, filepath.Join(root, "c"))
+	if err = os.WriteFile(filepath.Join(root, "a", "x.proto"), []byte("message"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	next, d, err := Diff(&loaded, Cache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := SnapshotDiff{Added: []string{"a/b/n.proto"}, Removed: []string{"c/z.proto"}, Changed: []string{"a/x.proto"}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Diff() = %+v want %+v", d, want)
+	}
+	if len(next.Files) != 3 {
+		t.Errorf("Diff() snapshot = %+v want 3 files", next.Files)
+	}
+}
+
+func TestSnapshotDirs(t *testing.T) {
+	root := makeTestTree(t, "a/x.proto", "b/y.proto", "b/c/z.proto")
+
+	prev, err := Snapshot("**/*.proto", root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prev.Dirs) != 4 {
+		t.Fatalf("Snapshot() dirs = %+v want 4 dirs", prev.Dirs)
+	}
+
+	// A loaded snapshot, as from the last run.
+	data, err := json.Marshal(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded GlobSnapshot
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plant an entry in each recorded dir: it shows up only if the dir is not read again.
+	for i := range loaded.Dirs {
+		loaded.Dirs[i].Entries = append(loaded.Dirs[i].Entries, SnapshotEntry{Name: "planted.proto"})
+	}
+	touchDir(t, filepath.Join(root, "b"))
+
+	next, d, err := Diff(&loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a/planted.proto", "b/c/planted.proto", "planted.proto"}
+	if !reflect.DeepEqual(d.Added, want) || len(d.Removed) != 0 {
+		t.Errorf("Diff() = %+v want the planted entries of the unchanged dirs %q", d, want)
+	}
+	if len(next.Dirs) != 4 {
+		t.Errorf("Diff() dirs = %+v want 4 dirs", next.Dirs)
+	}
+}
+
+func TestSnapshotCompare(t *testing.T) {
+	mtime := time.Unix(1, 0)
+
+	prev := &GlobSnapshot{Files: []SnapshotFile{{Path: "c"}, {Path: "a"}, {Path: "b"}}}
+	s := &GlobSnapshot{Files: []SnapshotFile{{Path: "b", Size: 1}, {Path: "d"}}}
+
+	want := SnapshotDiff{Added: []string{"d"}, Removed: []string{"a", "c"}, Changed: []string{}}
+	if d := s.Compare(prev); !reflect.DeepEqual(d, want) {
+		t.Errorf("Compare() without info = %+v want %+v", d, want)
+	}
+
+	prev.WithInfo, s.WithInfo = true, true
+	prev.Files[2].ModTime = &mtime
+	s.Files[0].ModTime = &mtime
+
+	want.Changed = []string{"b"}
+	if d := s.Compare(prev); !reflect.DeepEqual(d, want) {
+		t.Errorf("Compare() with info = %+v want %+v", d, want)
+	}
+
+	if d := s.Compare(nil); !reflect.DeepEqual(d.Added, []string{"b", "d"}) || len(d.Removed) != 0 {
+		t.Errorf("Compare(nil) = %+v want all added", d)
+	}
+}
@@ -0,0 +1,96 @@
+package expath
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SplitPattern separates the pattern into the static base directory and the dynamic remainder,
+// for example, `/var/log/app/**/2024-*.log` is separated into `/var/log/app` and `**/2024-*.log`.
+//
+// The base is the longest leading directory path that contains no meta characters ('*', '?', '[' or '{'),
+// so it may be empty (such as `*.go` or `**/*.go`), or just the root (such as `/*.go`).
+// The base is a literal path: the escaped characters (by '\\', except on Windows) are unescaped,
+// and it's cleaned by filepath.Clean, so `a/./b/../*.go` has the base `a`.
+// The leading '..' directories (such as `../`) belong to the base, as the glob routine uses them as the root.
+// The last element of the pattern always belongs to the remainder, even if it contains no meta characters.
+//
+func SplitPattern(pattern string) (base, rest string) {
+	pattern = plainSeparators(pattern)
+
+	nLen := len(pattern)
+	from := len(filepath.VolumeName(pattern))
+
+	mark := -1
+	escaped := false
+
+scan:
+	for i := from; i < nLen; {
+		c, esc, next := patternChar(pattern, i)
+
+		switch {
+		case esc:
+			escaped = true
+		case isSeparatorChar(c):
+			mark = i
+		case isMetaChar(c, esc), c == '{':
+			break scan
+		}
+
+		i = next
+	}
+
+	if mark < 0 {
+		return pattern[:from], pattern[from:]
+	}
+
+	base, rest = pattern[:mark], pattern[mark+1:]
+	if mark == from { // The root
+		base = pattern[:mark+1]
+	}
+
+	if escaped {
+		base = unescape(base)
+	}
+	return filepath.Clean(base), rest
+}
+
+// Base returns the static base directory of the pattern, the same as the SplitPattern's base.
+//
+func Base(pattern string) string {
+	base, _ := SplitPattern(pattern)
+	return base
+}
+
+// Rel returns the path of the matched name that relative to the base (as returned by SplitPattern).
+// It is lexical: both are cleaned by filepath.Clean, and an error is returned if the name is not the base itself
+// or under the base.
+//
+func Rel(base, name string) (string, error) {
+	nLen := len(base)
+	if nLen == 0 {
+		return name, nil
+	}
+
+	base, name = filepath.Clean(base), filepath.Clean(name)
+	if base == "." {
+		return name, nil
+	}
+	nLen = len(base)
+
+	if strings.HasPrefix(name, base) {
+		rest := name[nLen:]
+
+		switch {
+		case len(rest) == 0:
+			return ".", nil
+		case isDirSeparator(base, nLen-1):
+			return rest, nil
+		case isDirSeparator(rest, 0):
+			return rest[1:], nil
+		}
+	}
+
+	return "", fmt.Errorf("expath: %q is not under the base %q", name, base)
+}
@@ -0,0 +1,88 @@
+package expath
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+type splitPatternTest struct {
+	pattern, base, rest string
+}
+
+var splitPatternTests = []splitPatternTest{
+	{"/var/log/app/**/2024-*.log", "/var/log/app", "**/2024-*.log"},
+	{"var/log/app/**/2024-*.log", "var/log/app", "**/2024-*.log"},
+	{"a/b*/c", "a", "b*/c"},
+	{"a/b/c.txt", "a/b", "c.txt"},
+	{"a/b/", "a/b", ""},
+	{"a/b/{x,y}/*", "a/b", "{x,y}/*"},
+	{"a/[b]/c", "a", "[b]/c"},
+	{"a/?/c", "a", "?/c"},
+
+	{"../x/*.go", "../x", "*.go"},
+	{"./x/**", "x", "**"},
+	{"a/./b/../*.go", "a", "*.go"},
+	{"a/b/../../../x/*", "../x", "*"},
+	{"a//b/*", "a/b", "*"},
+
+	{"*.go", "", "*.go"},
+	{"**/*.go", "", "**/*.go"},
+	{"abc", "", "abc"},
+	{"/*.go", "/", "*.go"},
+	{"/**/*.go", "/", "**/*.go"},
+	{"/abc", "/", "abc"},
+	{"/", "/", ""},
+	{"", "", ""},
+}
+
+var splitPatternEscapedTests = []splitPatternTest{
+	{`a\*b/c/*.go`, "a*b/c", "*.go"},
+	{`a/\[x\]/*/d`, "a/[x]", "*/d"},
+	{`a/b\*`, "a", `b\*`},
+}
+
+func TestSplitPattern(t *testing.T) {
+	tests := splitPatternTests
+	if runtime.GOOS != "windows" {
+		tests = append(tests, splitPatternEscapedTests...)
+	}
+
+	for _, tt := range tests {
+		base, rest := SplitPattern(tt.pattern)
+		if base != filepath.FromSlash(tt.base) || rest != tt.rest {
+			t.Errorf("SplitPattern(%#q) = %#q, %#q want %#q, %#q", tt.pattern, base, rest, tt.base, tt.rest)
+		}
+		if base := Base(tt.pattern); base != filepath.FromSlash(tt.base) {
+			t.Errorf("Base(%#q) = %#q want %#q", tt.pattern, base, tt.base)
+		}
+	}
+}
+
+type relTest struct {
+	base, name, rel string
+	ok              bool
+}
+
+func TestRel(t *testing.T) {
+	tests := []relTest{
+		{"/var/log/app", "/var/log/app/x/2024-01.log", "x/2024-01.log", true},
+		{"/var/log/app", "/var/log/app", ".", true},
+		{"/var/log/app", "/var/log/application/a.log", "", false},
+		{"/var/log/app", "var/log/app/a.log", "", false},
+		{"/", "/a/b", "a/b", true},
+		{"", "a/b", "a/b", true},
+		{"../x", "../x/a.go", "a.go", true},
+		{"a", "a/./b/../c", "c", true},
+		{"a", "a/../b", "", false},
+		{".", "./a/b", "a/b", true},
+	}
+
+	for _, tt := range tests {
+		rel, err := Rel(tt.base, tt.name)
+		if rel != filepath.FromSlash(tt.rel) || (err == nil) != tt.ok {
+			t.Errorf("Rel(%#q, %#q) = %#q, %q want %#q, ok %v", tt.base, tt.name, rel, errp(err), tt.rel, tt.ok)
+		}
+	}
+
+}
@@ -0,0 +1,430 @@
+package expath
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrBadTemplate indicates a destination template of the Transform is malformed.
+//
+var ErrBadTemplate = errors.New("expath: bad destination template")
+
+// Transform rewrites the paths matching a source pattern into the paths described by a destination template,
+// in the mmv style, such as moving every `legacy/**/*.js` to `src/**/*.ts`.
+//
+// The destination template refers to the captures of the source pattern's wildcards (see MatchCaptures):
+//
+//	'$n' or '${n}'  by position, the n-th (from 1) wildcard of the source pattern
+//	'${name}'       by name, the wildcard named in the source pattern
+//	'**'            by kind, the next '**' of the source pattern
+//	'*'             by kind, the next '*' of the source pattern
+//	'?'             by kind, the next '?' or '[...]' of the source pattern
+//	'$$'            a literal '$'
+//
+// A wildcard of the source pattern is named by enclosing it as '{name:wildcard}', such as `legacy/{dir:**}/{base:*}.js`
+// rewritten by `src/${dir}/${base}.ts`. The name is of letters, digits and '_' (not beginning with a digit),
+// and the names are stripped from the Source pattern.
+//
+// The other characters of the template are literal, a character can also be escaped by '\\' (except on Windows).
+// When a '**' capture is empty, the separator following (or else preceding) the '**' is dropped,
+// so `src/**/*.ts` rewrites `legacy/a.js` (matched by `legacy/**/*.js`) into `src/a.ts`.
+//
+// A Transform is safe for concurrent use by multiple goroutines.
+//
+type Transform struct {
+	src   *Pattern
+	parts []templatePart
+}
+
+// templatePart is either a literal text (capture < 0), or a reference to the capture (anyDirs if it is a '**').
+//
+type templatePart struct {
+	literal string
+	capture int
+	anyDirs bool
+}
+
+// Rename is a path rewritten by the Transform.
+//
+type Rename struct {
+	From, To string
+}
+
+// Collision records the sources that are rewritten into the same destination.
+//
+type Collision struct {
+	To   string
+	From []string
+}
+
+// CollisionError is returned by the Transform if two or more sources are rewritten into the same destination.
+//
+type CollisionError struct {
+	Collisions []Collision
+}
+
+func (e *CollisionError) Error() string {
+	var b strings.Builder
+	b.WriteString("expath: transform collisions:")
+	for _, c := range e.Collisions {
+		fmt.Fprintf(&b, " %q <- %q;", c.To, c.From)
+	}
+	return strings.TrimSuffix(b.String(), ";")
+}
+
+// NewTransform compiles the source pattern and parses the destination template.
+// filepath.ErrBadPattern is returned if the source pattern is malformed,
+// or ErrBadTemplate (wrapped) if the destination template is malformed or refers to a missing wildcard.
+//
+func NewTransform(src, dest string) (*Transform, error) {
+	pattern, named, err := parseNamedWildcards(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// The named wildcards are counted in the text, which must be the same as the captures.
+	kinds := wildcardKinds(p.segs)
+	if len(named) > 0 && countWildcards(pattern) != len(kinds) {
+		return nil, errBadPattern
+	}
+
+	parts, err := parseTemplate(dest, kinds, named)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transform{p, parts}, nil
+}
+
+// parseNamedWildcards strips the names of the named wildcards ('{name:wildcard}') from the source pattern,
+// and returns the index of each named wildcard among all the wildcards of the pattern.
+// A '{' not followed by a name and ':' is literal.
+//
+func parseNamedWildcards(src string) (pattern string, named map[string]int, err error) {
+	if strings.IndexByte(src, '{') < 0 {
+		return src, nil, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(src))
+
+	for i := 0; i < len(src); {
+		c, escaped, next := patternChar(src, i)
+
+		colon := strings.IndexByte(src[next:], ':')
+		if c != '{' || escaped || colon < 0 || !isVarName(src[next:next+colon]) {
+			b.WriteString(src[i:next])
+			i = next
+			continue
+		}
+
+		name, inner := src[next:next+colon], src[next+colon+1:]
+
+		n := wildcardLen(inner)
+		if n == 0 || n >= len(inner) || inner[n] != '}' {
+			return "", nil, errBadPattern
+		}
+		if _, ok := named[name]; ok {
+			return "", nil, errBadPattern
+		}
+
+		if named == nil {
+			named = make(map[string]int)
+		}
+		named[name] = countWildcards(b.String())
+
+		b.WriteString(inner[:n])
+		i = next + colon + 1 + n + 1
+	}
+
+	return b.String(), named, nil
+}
+
+// wildcardLen returns the length of the wildcard ('*'s, '?' or '[...]') that the pattern begins with,
+// 0 if none.
+//
+func wildcardLen(pattern string) int {
+	if pattern == "" {
+		return 0
+	}
+
+	switch pattern[0] {
+	case '*':
+		n := 1
+		for n < len(pattern) && pattern[n] == '*' {
+			n++
+		}
+		return n
+	case '?':
+		return 1
+	case '[':
+		if _, rest, err := matchClass(pattern[1:], 0); err == nil {
+			return len(pattern) - len(rest)
+		}
+	}
+	return 0
+}
+
+// countWildcards counts the wildcards in the text of the pattern, a run of '*'s (including '**') is one.
+//
+func countWildcards(pattern string) int {
+	count := 0
+	for i := 0; i < len(pattern); {
+		_, escaped, next := patternChar(pattern, i)
+		if !escaped {
+			if n := wildcardLen(pattern[i:]); n > 0 {
+				count++
+				next = i + n
+			}
+		}
+		i = next
+	}
+	return count
+}
+
+func parseTemplate(template string, kinds []wildcardKind, named map[string]int) ([]templatePart, error) {
+	var parts []templatePart
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, templatePart{literal.String(), -1, false})
+			literal.Reset()
+		}
+	}
+
+	badTemplate := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w %q: %s", ErrBadTemplate, template, fmt.Sprintf(format, args...))
+	}
+
+	var next [charWildcard + 1]int // the next capture of each kind to refer by name
+
+	refer := func(kind wildcardKind, token string) error {
+		for i := next[kind]; i < len(kinds); i++ {
+			if kinds[i] == kind {
+				next[kind] = i + 1
+				flush()
+				parts = append(parts, templatePart{"", i, kind == anyDirsWildcard})
+				return nil
+			}
+		}
+		return badTemplate("no more %#q wildcard in the source pattern", token)
+	}
+
+	nLen := len(template)
+	for i := 0; i < nLen; i++ {
+		c := template[i]
+
+		switch {
+		case c == '\\' && runtime.GOOS != "windows":
+			i++
+			if i >= nLen {
+				return nil, badTemplate("trailing '\\\\'")
+			}
+			literal.WriteByte(template[i])
+
+		case c == '$':
+			i++
+			if i < nLen && template[i] == '$' {
+				literal.WriteByte('$')
+				continue
+			}
+
+			var num string
+			if i < nLen && template[i] == '{' {
+				end := strings.IndexByte(template[i:], '}')
+				if end < 0 {
+					return nil, badTemplate("missing '}'")
+				}
+				num, i = template[i+1:i+end], i+end
+			} else {
+				j := i
+				for j < nLen && '0' <= template[j] && template[j] <= '9' {
+					j++
+				}
+				num, i = template[i:j], j-1
+			}
+
+			n, err := strconv.Atoi(num)
+			if index, ok := named[num]; ok && err != nil {
+				n, err = index+1, nil
+			}
+			if err != nil || n < 1 || n > len(kinds) {
+				return nil, badTemplate("invalid reference $%s (the source pattern has %d wildcards)", num, len(kinds))
+			}
+
+			flush()
+			parts = append(parts, templatePart{"", n - 1, kinds[n-1] == anyDirsWildcard})
+
+		case c == '*':
+			if i+1 < nLen && template[i+1] == '*' {
+				for i+1 < nLen && template[i+1] == '*' {
+					i++
+				}
+				if err := refer(anyDirsWildcard, "**"); err != nil {
+					return nil, err
+				}
+			} else if err := refer(starWildcard, "*"); err != nil {
+				return nil, err
+			}
+
+		case c == '?':
+			if err := refer(charWildcard, "?"); err != nil {
+				return nil, err
+			}
+
+		default:
+			literal.WriteByte(c)
+		}
+	}
+
+	flush()
+	return parts, nil
+}
+
+// Source returns the source pattern of the Transform.
+//
+func (t *Transform) Source() *Pattern {
+	return t.src
+}
+
+// Rewrite rewrites the name into the destination path, or reports false if the name doesn't match
+// the source pattern.
+//
+func (t *Transform) Rewrite(name string) (string, bool) {
+	captures, matched := t.src.MatchCaptures(name)
+	if !matched {
+		return "", false
+	}
+	return t.expand(captures), true
+}
+
+func (t *Transform) expand(captures []string) string {
+	buf := make([]byte, 0, 64)
+	skipSep := false
+
+	for i, part := range t.parts {
+		if part.capture < 0 {
+			text := part.literal
+			if skipSep && isDirSeparator(text, 0) {
+				text = text[1:]
+			}
+			skipSep = false
+
+			buf = append(buf, text...)
+			continue
+		}
+
+		text := captures[part.capture]
+
+		// Drop the separator following (or else preceding) an empty '**' capture.
+		if part.anyDirs && len(text) == 0 {
+			if i+1 < len(t.parts) && t.parts[i+1].capture < 0 && isDirSeparator(t.parts[i+1].literal, 0) {
+				skipSep = true
+			} else if n := len(buf); n > 1 && isDirSeparator(string(buf[n-1:]), 0) {
+				buf = buf[:n-1]
+			}
+		}
+
+		buf = append(buf, text...)
+	}
+
+	return string(buf)
+}
+
+// RewriteAll rewrites each of the names matching the source pattern, in order,
+// the names that don't match are skipped.
+// If two or more names are rewritten into the same destination, the renames are returned with
+// a *CollisionError.
+//
+func (t *Transform) RewriteAll(names []string) ([]Rename, error) {
+	var renames []Rename
+
+	for _, name := range names {
+		if to, ok := t.Rewrite(name); ok {
+			renames = append(renames, Rename{name, to})
+		}
+	}
+
+	return renames, checkCollisions(renames)
+}
+
+// Glob globs the source pattern based on the root (see Glob), and rewrites each of the matched files.
+// The From of each Rename is the matched path in the form set by the Output option (relative to the returned
+// atRoot by default), as Glob returns, and the To is rewritten from the relative path.
+// With the ExpandPattern option, the files are matched by the expanded source pattern (the wildcards are the same).
+// If two or more files are rewritten into the same destination, the renames are returned with
+// a *CollisionError.
+//
+func (t *Transform) Glob(root string, opts ...GlobOption) (renames []Rename, atRoot string, err error) {
+	o := newGlobOptions(opts)
+
+	// The files are globbed with the relative paths to be rewritten, and then put in the form of the output.
+	out := matchedSet{output: o.output}
+	o.output = RelativePath
+
+	var helper filePathHelper
+	var mh matchedSet
+	err = o.glob(t.src.pattern, root, &helper, &mh)
+	atRoot = mh.root
+	if err != nil {
+		return nil, atRoot, err
+	}
+	if err = out.setRoot(atRoot); err != nil {
+		return nil, atRoot, err
+	}
+
+	// The matched paths are based on the expanded and normalized pattern, which is separated from the root.
+	pattern := t.src.pattern
+	if o.expander != nil {
+		if _, pattern, err = o.expander.expand(pattern); err != nil {
+			return nil, atRoot, err
+		}
+	}
+	pattern, _ = normalizePath(pattern, root)
+	src := t.src
+	if pattern != src.pattern {
+		if src, err = Compile(pattern); err != nil {
+			return nil, atRoot, err
+		}
+	}
+
+	for _, matched := range mh.matches {
+		if captures, ok := src.MatchCaptures(matched); ok {
+			renames = append(renames, Rename{out.outputOf(matched), t.expand(captures)})
+		}
+	}
+
+	return renames, atRoot, checkCollisions(renames)
+}
+
+func checkCollisions(renames []Rename) error {
+	froms := make(map[string][]string, len(renames))
+	for _, r := range renames {
+		froms[r.To] = append(froms[r.To], r.From)
+	}
+
+	var collisions []Collision
+	for to, from := range froms {
+		if len(from) > 1 {
+			collisions = append(collisions, Collision{to, from})
+		}
+	}
+	if collisions == nil {
+		return nil
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].To < collisions[j].To
+	})
+	return &CollisionError{collisions}
+}
@@ -0,0 +1,177 @@
+package expath
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+type rewriteTest struct {
+	src, dest, name, to string
+	ok                  bool
+}
+
+func TestTransformRewrite(t *testing.T) {
+	tests := []rewriteTest{
+		{"legacy/**/*.js", "src/**/*.ts", "legacy/a/b/c.js", "src/a/b/c.ts", true},
+		{"legacy/**/*.js", "src/**/*.ts", "legacy/c.js", "src/c.ts", true},
+		{"legacy/**/*.js", "src/**/*.ts", "legacy/c.ts", "", false},
+		{"legacy/**/*.js", "src/$2/$1.ts", "legacy/a/b/c.js", "src/c/a/b.ts", true},
+		{"legacy/**/*.js", "src/${2}_${1}.ts", "legacy/a/c.js", "src/c_a.ts", true},
+		{"legacy/**/*.js", "out/**", "legacy/a/c.js", "out/a", true},
+		{"legacy/**/*.js", "out/**", "legacy/c.js", "out", true},
+		{"**/*.js", "/**/*.mjs", "c.js", "/c.mjs", true},
+		{"img-??.png", "img/?/?.png", "img-ab.png", "img/a/b.png", true},
+		{"[a-c]*.txt", "$1/$2.txt", "alpha.txt", "a/lpha.txt", true},
+		{"*.*", "*.$$*", "a.b", "a.$b", true},
+		{"legacy/{dir:**}/{base:*}.js", "src/${base}/${dir}.ts", "legacy/a/b/c.js", "src/c/a/b.ts", true},
+		{"legacy/{dir:**}/{base:*}.js", "src/${dir}/${base}.ts", "legacy/c.js", "src/c.ts", true},
+		{"{x:?}{y:[0-9]}-*.txt", "${y}/${x}/$3.txt", "a1-b.txt", "1/a/b.txt", true},
+		{"{x:?}{y:[0-9]}-*.txt", "${y}/*.txt", "a1-b.txt", "1/b.txt", true},
+		{"{a,b}/{n:*}", "${n}", "{a,b}/c", "c", true},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, rewriteTest{"*.go", `\*/*.go`, "a.go", "*/a.go", true})
+	}
+
+	for _, tt := range tests {
+		tr, err := NewTransform(tt.src, tt.dest)
+		if err != nil {
+			t.Errorf("NewTransform(%#q, %#q) = %q", tt.src, tt.dest, errp(err))
+			continue
+		}
+
+		to, ok := tr.Rewrite(tt.name)
+		if to != tt.to || ok != tt.ok {
+			t.Errorf("NewTransform(%#q, %#q).Rewrite(%#q) = %#q, %v want %#q, %v", tt.src, tt.dest, tt.name, to, ok, tt.to, tt.ok)
+		}
+	}
+}
+
+func TestTransformBadTemplate(t *testing.T) {
+	tests := []struct{ src, dest string }{
+		{"*.js", "**/*.ts"},
+		{"*.js", "*/*.ts"},
+		{"*.js", "$2.ts"},
+		{"*.js", "$0.ts"},
+		{"*.js", "${1.ts"},
+		{"*.js", "?.ts"},
+		{"*.js", "$.ts"},
+		{"{n:*}.js", "${m}.ts"},
+		{"{n:*}.js", "$n.ts"},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewTransform(tt.src, tt.dest); !errors.Is(err, ErrBadTemplate) {
+			t.Errorf("NewTransform(%#q, %#q) = %q want ErrBadTemplate", tt.src, tt.dest, errp(err))
+		}
+	}
+
+	for _, src := range []string{"[", "{n:}", "{n:a*}", "{n:*", "{n:*}/{n:?}", "{n:**}/**"} {
+		if _, err := NewTransform(src, "x"); err != errBadPattern {
+			t.Errorf("NewTransform(%#q, `x`) = %q want %q", src, errp(err), errp(errBadPattern))
+		}
+	}
+}
+
+func TestTransformRewriteAll(t *testing.T) {
+	tr, err := NewTransform("**/*.js", "out/*.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renames, err := tr.RewriteAll([]string{"a/x.js", "b/y.js", "README", "c/x.js", "d/e/x.js"})
+
+	want := []Rename{{"a/x.js", "out/x.js"}, {"b/y.js", "out/y.js"}, {"c/x.js", "out/x.js"}, {"d/e/x.js", "out/x.js"}}
+	if !reflect.DeepEqual(renames, want) {
+		t.Errorf("RewriteAll() = %v want %v", renames, want)
+	}
+
+	var ce *CollisionError
+	if !errors.As(err, &ce) {
+		t.Fatalf("RewriteAll() error = %q want *CollisionError", errp(err))
+	}
+	wantCollisions := []Collision{{"out/x.js", []string{"a/x.js", "c/x.js", "d/e/x.js"}}}
+	if !reflect.DeepEqual(ce.Collisions, wantCollisions) {
+		t.Errorf("RewriteAll() collisions = %v want %v", ce.Collisions, wantCollisions)
+	}
+
+	if _, err = tr.RewriteAll([]string{"a/x.js", "b/y.js"}); err != nil {
+		t.Errorf("RewriteAll() = %q want no collision", errp(err))
+	}
+}
+
+func TestTransformGlob(t *testing.T) {
+	root := makeTestTree(t, "legacy/a/b.js", "legacy/c.js", "legacy/d.txt")
+
+	for _, src := range []string{"legacy/**/*.js", "./legacy/**/*.js"} {
+		tr, err := NewTransform(src, "src/**/*.ts")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		renames, _, err := tr.Glob(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(map[string]string)
+		for _, r := range renames {
+			got[trimPath(r.From)] = r.To
+		}
+
+		want := map[string]string{"legacy/a/b.js": "src/a/b.ts", "legacy/c.js": "src/c.ts"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("NewTransform(%#q, `src/**/*.ts`).Glob() = %v want %v", src, got, want)
+		}
+	}
+}
+
+func TestTransformGlobOptions(t *testing.T) {
+	root := makeTestTree(t, "legacy/a/b.js", "legacy/c.js", "legacy/d.txt")
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(root, "legacy")
+
+	expand := ExpandPattern(
+		LookupEnv(func(name string) (string, bool) { return "legacy", name == "DIR" }),
+		LookupHome(func(string) (string, error) { return legacy, nil }),
+	)
+
+	tests := []struct {
+		src  string
+		opt  GlobOption
+		want map[string]string
+	}{
+		{"legacy/**/*.js", Output(FullPath), map[string]string{
+			filepath.Join(root, "legacy", "a", "b.js"): "src/a/b.ts", filepath.Join(root, "legacy", "c.js"): "src/c.ts"}},
+		{"legacy/**/*.js", Output(AbsolutePath), map[string]string{
+			filepath.Join(abs, "legacy", "a", "b.js"): "src/a/b.ts", filepath.Join(abs, "legacy", "c.js"): "src/c.ts"}},
+		{"$DIR/**/*.js", expand, map[string]string{"legacy/a/b.js": "src/a/b.ts", "legacy/c.js": "src/c.ts"}},
+		{"~/**/*.js", expand, map[string]string{"a/b.js": "src/a/b.ts", "c.js": "src/c.ts"}},
+	}
+
+	for _, tt := range tests {
+		tr, err := NewTransform(tt.src, "src/**/*.ts")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		renames, _, err := tr.Glob(root, tt.opt)
+		if err != nil {
+			t.Fatalf("NewTransform(%#q).Glob() error: %v", tt.src, err)
+		}
+
+		got := make(map[string]string)
+		for _, r := range renames {
+			got[r.From] = r.To
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NewTransform(%#q).Glob() = %v want %v", tt.src, got, tt.want)
+		}
+	}
+}